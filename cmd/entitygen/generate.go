@@ -0,0 +1,278 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Generate generates the source code and the tests for a schema
+func Generate(schema *Schema) (code, test []byte, err error) {
+	if code, err = render(codeTemplate, schema, codeImports(schema)); err != nil {
+		return nil, nil, err
+	}
+
+	if test, err = render(testTemplate, schema, testImports(schema)); err != nil {
+		return nil, nil, err
+	}
+	return code, test, nil
+}
+
+// render executes the template and formats the resulting source code
+func render(tmpl *template.Template, schema *Schema, imports []string) ([]byte, error) {
+	var std, other []string
+	for _, path := range imports {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			other = append(other, path)
+			continue
+		}
+		std = append(std, path)
+	}
+
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, struct {
+		*Schema
+		Imports [][]string
+	}{schema, [][]string{std, other}}); err != nil {
+		return nil, err
+	}
+
+	return format.Source(buffer.Bytes())
+}
+
+// codeImports returns the imports of the generated source code
+func codeImports(schema *Schema) []string {
+	used := map[string]bool{
+		"github.com/kelindar/column":     true,
+		"github.com/kelindar/ecs/entity": true,
+	}
+
+	for _, c := range schema.Components {
+		qualifiers(used, c.Type, c.Decode, c.Encode, c.Record)
+		if c.Index != nil {
+			qualifiers(used, c.Index.Predicate)
+		}
+	}
+	return sorted(used)
+}
+
+// testImports returns the imports of the generated tests
+func testImports(schema *Schema) []string {
+	used := map[string]bool{
+		"testing":                            true,
+		"github.com/stretchr/testify/assert": true,
+	}
+
+	for _, c := range schema.Components {
+		qualifiers(used, c.Type, c.Sample)
+	}
+	return sorted(used)
+}
+
+// qualifier matches the package qualifiers within an expression
+var qualifier = regexp.MustCompile(`\b([a-z]+)\.[A-Z]`)
+
+// qualifiers adds the import paths of the packages used by the expressions
+func qualifiers(used map[string]bool, expressions ...string) {
+	for _, expr := range expressions {
+		for _, match := range qualifier.FindAllStringSubmatch(expr, -1) {
+			if path, ok := imports[match[1]]; ok {
+				used[path] = true
+			}
+		}
+	}
+}
+
+// sorted returns the sorted import paths
+func sorted(used map[string]bool) []string {
+	out := make([]string, 0, len(used))
+	for path := range used {
+		out = append(out, path)
+	}
+
+	sort.Strings(out)
+	return out
+}
+
+// funcs contains the helper functions of the templates
+var funcs = template.FuncMap{
+	"storage": func(c Component) string {
+		return storages[c.Storage]
+	},
+}
+
+var codeTemplate = template.Must(template.New("code").Funcs(funcs).Parse(`// Code generated by entitygen; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range $i, $group := .Imports}}{{if and $i (index $.Imports 0)}}
+{{end}}{{range $group}}
+	"{{.}}"
+{{- end}}{{end}}
+)
+
+// Collection represents a collection of {{.Noun}}s
+type Collection = entity.Collection[{{.Entity}}]
+
+// NewCollection creates a new {{.Noun}} collection
+func NewCollection() *Collection {
+	db := entity.NewCollection("{{.File}}", {{.Version}}, fromTxn)
+{{- range .Components}}
+	db.CreateColumn("{{.Column}}", column.For{{storage .}}()) {{with .Comment}}// {{.}}{{end}}
+{{- end}}
+{{- range .Components}}{{if .Index}}
+	db.CreateIndex("{{.Index.Name}}", "{{.Column}}", func(r column.Reader) bool {
+		return {{.Index.Predicate}}
+	})
+{{- end}}{{end}}
+	return db
+}
+
+// {{.Entity}} represents a view on a current row
+type {{.Entity}} struct {
+	id interface {
+		Get() (string, bool)
+	}
+{{- range .Components}}
+	{{.Column}} interface {
+{{- if eq .Storage "bool"}}
+		Get() bool
+{{- else}}
+		Get() ({{.Storage}}, bool)
+{{- end}}
+		Set(value {{.Storage}})
+	}
+{{- end}}
+}
+
+// fromTxn creates a statically-typed mapping for a transaction
+func fromTxn(txn *column.Txn) {{.Entity}} {
+	return {{.Entity}}{
+		id: txn.Key(),
+{{- range .Components}}
+		{{.Column}}: txn.{{storage .}}("{{.Column}}"),
+{{- end}}
+	}
+}
+
+// ID returns the unique identifier of the {{.Noun}}
+func (e *{{.Entity}}) ID() string {
+	v, _ := e.id.Get()
+	return v
+}
+{{- $entity := .Entity}}
+{{range .Components}}
+// ---------------------------------- {{.Name}} ----------------------------------
+
+// {{.Name}} reads the {{.Doc}}
+func (e *{{$entity}}) {{.Name}}() {{.Type}} {
+{{- if eq .Storage "bool"}}
+	v := e.{{.Column}}.Get()
+{{- else}}
+	v, _ := e.{{.Column}}.Get()
+{{- end}}
+	return {{.Decode}}
+}
+
+// Set{{.Name}} writes the {{.Doc}}
+func (e *{{$entity}}) Set{{.Name}}(v {{.Type}}) {
+	e.{{.Column}}.Set({{.Encode}})
+}
+{{end}}
+// ---------------------------------- Record ----------------------------------
+
+// Record represents a {{.Noun}} as a plain record, keyed by its unique identifier
+// and with its components decoded, which can be encoded in JSON.
+type Record struct {
+	ID string ` + "`" + `json:"id"` + "`" + `
+{{- range .Components}}
+	{{.Name}} {{.Record}} ` + "`" + `json:"{{.Column}}"` + "`" + `
+{{- end}}
+}
+
+// Record reads all of the components into a record
+func (e *{{.Entity}}) Record() Record {
+	return Record{
+		ID: e.ID(),
+{{- range .Components}}
+{{- if eq .Record .Type}}
+		{{.Name}}: e.{{.Name}}(),
+{{- else}}
+		{{.Name}}: {{.Record}}(e.{{.Name}}()),
+{{- end}}
+{{- end}}
+	}
+}
+
+// SetRecord writes all of the components from a record, except for the identifier
+func (e *{{.Entity}}) SetRecord(r Record) {
+{{- range .Components}}
+{{- if eq .Record .Type}}
+	e.Set{{.Name}}(r.{{.Name}})
+{{- else}}
+	e.Set{{.Name}}({{.Type}}(r.{{.Name}}))
+{{- end}}
+{{- end}}
+}
+`))
+
+var testTemplate = template.Must(template.New("test").Funcs(funcs).Parse(`// Code generated by entitygen; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range $i, $group := .Imports}}{{if and $i (index $.Imports 0)}}
+{{end}}{{range $group}}
+	"{{.}}"
+{{- end}}{{end}}
+)
+
+func TestGenerated{{.Entity}}(t *testing.T) {
+{{- range .Components}}
+	var want{{.Name}} {{.Type}} = {{.Sample}}
+{{- end}}
+
+	c := NewCollection()
+	id, err := c.Insert(func(v {{.Entity}}) error {
+{{- range .Components}}
+		v.Set{{.Name}}(want{{.Name}})
+{{- end}}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, c.Count())
+
+	// Read back every component
+	assert.NoError(t, c.Get(id, func(v {{.Entity}}) error {
+		assert.Equal(t, id, v.ID())
+{{- range .Components}}
+		assert.Equal(t, want{{.Name}}, v.{{.Name}}())
+{{- end}}
+		return nil
+	}))
+
+	// Copy the components through a record
+	var record Record
+	assert.NoError(t, c.Get(id, func(v {{.Entity}}) error {
+		record = v.Record()
+		return nil
+	}))
+	assert.Equal(t, id, record.ID)
+
+	clone, err := c.Insert(func(v {{.Entity}}) error {
+		v.SetRecord(record)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Get(clone, func(v {{.Entity}}) error {
+{{- range .Components}}
+		assert.Equal(t, want{{.Name}}, v.{{.Name}}())
+{{- end}}
+		return nil
+	}))
+}
+`))
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateUpToDate(t *testing.T) {
+	schemas, err := filepath.Glob("../../entity/*/*.json")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, schemas)
+
+	for _, filename := range schemas {
+		schema, err := ReadSchema(filename)
+		assert.NoError(t, err)
+
+		code, test, err := Generate(schema)
+		assert.NoError(t, err)
+
+		base := strings.TrimSuffix(filename, filepath.Ext(filename))
+		expectCode, _ := os.ReadFile(base + "_gen.go")
+		expectTest, _ := os.ReadFile(base + "_gen_test.go")
+		assert.Equal(t, string(expectCode), string(code), "%s is out of date, run go generate", filename)
+		assert.Equal(t, string(expectTest), string(test), "%s is out of date, run go generate", filename)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	schema := &Schema{
+		Package: "player",
+		Entity:  "Player",
+		File:    "players.bin",
+		Components: []Component{
+			{Column: "name", Storage: "string", Name: "Name"},
+			{Column: "online", Storage: "bool", Name: "Online", Index: &Index{
+				Name:      "online",
+				Predicate: "r.Bool()",
+			}},
+			{Column: "hp", Storage: "uint16", Type: "int", Name: "Health"},
+		},
+	}
+
+	assert.NoError(t, schema.validate())
+	code, test, err := Generate(schema)
+	assert.NoError(t, err)
+	assert.Contains(t, string(code), `db := entity.NewCollection("players.bin", 1, fromTxn)`)
+	assert.Contains(t, string(code), `db.CreateColumn("name", column.ForString())`)
+	assert.Contains(t, string(code), `db.CreateIndex("online", "online", func(r column.Reader) bool {`)
+	assert.Contains(t, string(code), "v := e.online.Get()")
+	assert.Contains(t, string(code), "return int(v)")
+	assert.Contains(t, string(code), "e.hp.Set(uint16(v))")
+	assert.Contains(t, string(code), "// Collection represents a collection of players")
+	assert.Contains(t, string(code), "Health: e.Health(),")
+	assert.Contains(t, string(code), "func (e *Player) SetRecord(r Record) {")
+	assert.Contains(t, string(test), `var wantName string = "hello"`)
+	assert.Contains(t, string(test), "var wantOnline bool = true")
+	assert.NotContains(t, string(code), "tile")
+}
+
+func TestValidate(t *testing.T) {
+	tests := []Schema{
+		{Entity: "A", File: "a.bin"},
+		{Package: "a", File: "a.bin"},
+		{Package: "a", Entity: "A"},
+		{Package: "a", Entity: "A", File: "a.bin", Components: []Component{
+			{Column: "id", Storage: "uint32", Name: "X"},
+		}},
+		{Package: "a", Entity: "A", File: "a.bin", Components: []Component{
+			{Column: "x", Storage: "complex64", Name: "X"},
+		}},
+		{Package: "a", Entity: "A", File: "a.bin", Components: []Component{
+			{Column: "x", Storage: "uint32", Name: "x"},
+		}},
+		{Package: "a", Entity: "A", File: "a.bin", Components: []Component{
+			{Column: "X", Storage: "uint32", Name: "X"},
+		}},
+		{Package: "a", Entity: "A", File: "a.bin", Components: []Component{
+			{Column: "x", Storage: "uint32", Name: "X", Index: &Index{Name: "x"}},
+		}},
+	}
+
+	for _, tc := range tests {
+		assert.Error(t, tc.validate())
+	}
+}
+
+func TestReadSchema(t *testing.T) {
+	schema, err := ReadSchema("../../entity/mobile/mobile.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "Mobile", schema.Entity)
+	assert.Len(t, schema.Components, 6)
+
+	_, err = ReadSchema("missing.json")
+	assert.Error(t, err)
+}
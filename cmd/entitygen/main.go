@@ -0,0 +1,55 @@
+// Command entitygen generates statically-typed entity views from a declarative
+// component schema. For every schema, it generates the collection constructor,
+// the view struct, the transaction mapping, the accessors and their tests.
+//
+// Usage:
+//
+//	//go:generate go run ../../cmd/entitygen mobile.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: entitygen <schema.json>...")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	for _, filename := range flag.Args() {
+		if err := run(filename); err != nil {
+			log.Fatalf("entitygen: %v", err)
+		}
+	}
+}
+
+// run generates the code and the tests for a schema file
+func run(filename string) error {
+	schema, err := ReadSchema(filename)
+	if err != nil {
+		return err
+	}
+
+	code, test, err := Generate(schema)
+	if err != nil {
+		return err
+	}
+
+	base := strings.TrimSuffix(filename, filepath.Ext(filename))
+	if err := os.WriteFile(base+"_gen.go", code, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(base+"_gen_test.go", test, 0644)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Schema represents a declarative schema of an entity
+type Schema struct {
+	Package    string      `json:"package"`    // The name of the package
+	Entity     string      `json:"entity"`     // The name of the view, e.g. "Mobile"
+	Noun       string      `json:"noun"`       // The noun used in comments, e.g. "mobile object"
+	File       string      `json:"file"`       // The save file name, e.g. "mobiles.bin"
+	Version    uint32      `json:"version"`    // The version of the save, bumped with every breaking change
+	Components []Component `json:"components"` // The components of the entity
+}
+
+// Component represents a single component, stored in its own column
+type Component struct {
+	Column  string `json:"column"`           // The name of the column, e.g. "at"
+	Storage string `json:"storage"`          // The storage type of the column, e.g. "uint32"
+	Type    string `json:"type,omitempty"`   // The Go type of the accessor, defaults to the storage type
+	Name    string `json:"name"`             // The name of the accessor, e.g. "Location"
+	Doc     string `json:"doc"`              // The description used in comments, e.g. "current location"
+	Comment string `json:"comment"`          // The comment of the column
+	Decode  string `json:"decode,omitempty"` // The expression converting stored "v" into the Go type
+	Encode  string `json:"encode,omitempty"` // The expression converting Go "v" into the storage type
+	Sample  string `json:"sample,omitempty"` // The sample value expression used in tests
+	Record  string `json:"record,omitempty"` // The type of the field in records, defaults to the Go type
+	Index   *Index `json:"index,omitempty"`  // The optional index over the column
+}
+
+// Index represents an index created over a component column
+type Index struct {
+	Name      string `json:"name"`      // The name of the index
+	Predicate string `json:"predicate"` // The boolean expression over "r column.Reader"
+}
+
+// codec represents a known conversion between a Go type and its storage
+type codec struct {
+	decode string
+	encode string
+	sample string
+	record string
+}
+
+// codecs contains the conversions for the types that do not convert directly
+var codecs = map[string]codec{
+	"tile.Point": {
+		decode: "tile.At(int16(v>>16), int16(v))",
+		encode: "v.Integer()",
+		sample: "tile.At(1, 2)",
+		record: "state.Point",
+	},
+}
+
+// storages maps the supported storage types to the column constructor suffix
+var storages = map[string]string{
+	"string":  "String",
+	"bool":    "Bool",
+	"int":     "Int",
+	"int16":   "Int16",
+	"int32":   "Int32",
+	"int64":   "Int64",
+	"uint":    "Uint",
+	"uint16":  "Uint16",
+	"uint32":  "Uint32",
+	"uint64":  "Uint64",
+	"float32": "Float32",
+	"float64": "Float64",
+}
+
+// imports maps the package qualifiers to their import paths
+var imports = map[string]string{
+	"tile":  "github.com/kelindar/tile",
+	"state": "github.com/kelindar/ecs/state",
+	"time":  "time",
+}
+
+// ReadSchema reads and validates a schema from a JSON file
+func ReadSchema(filename string) (*Schema, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := new(Schema)
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	if err := schema.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return schema, nil
+}
+
+// validate validates the schema and applies the defaults
+func (s *Schema) validate() error {
+	switch {
+	case s.Package == "":
+		return fmt.Errorf("package is not specified")
+	case s.Entity == "":
+		return fmt.Errorf("entity is not specified")
+	case s.File == "":
+		return fmt.Errorf("file is not specified")
+	}
+
+	if s.Noun == "" {
+		s.Noun = strings.ToLower(s.Entity)
+	}
+
+	if s.Version == 0 {
+		s.Version = 1
+	}
+
+	seen := map[string]bool{"id": true}
+	for i := range s.Components {
+		c := &s.Components[i]
+		if seen[c.Column] {
+			return fmt.Errorf("column %q is declared more than once", c.Column)
+		}
+
+		seen[c.Column] = true
+		if err := c.validate(); err != nil {
+			return fmt.Errorf("column %q: %w", c.Column, err)
+		}
+	}
+	return nil
+}
+
+// validate validates the component and applies the defaults
+func (c *Component) validate() error {
+	if c.Column == "" || !isIdentifier(c.Column) {
+		return fmt.Errorf("invalid column name")
+	}
+
+	if _, ok := storages[c.Storage]; !ok {
+		return fmt.Errorf("unsupported storage type %q", c.Storage)
+	}
+
+	if c.Name == "" || !unicode.IsUpper(rune(c.Name[0])) {
+		return fmt.Errorf("accessor name must be exported")
+	}
+
+	if c.Type == "" {
+		c.Type = c.Storage
+	}
+
+	if c.Doc == "" {
+		c.Doc = strings.ToLower(c.Name)
+	}
+
+	// Apply the known conversions, otherwise convert the types directly
+	known, ok := codecs[c.Type]
+	switch {
+	case c.Decode == "" && ok:
+		c.Decode = known.decode
+	case c.Decode == "" && c.Type == c.Storage:
+		c.Decode = "v"
+	case c.Decode == "":
+		c.Decode = fmt.Sprintf("%s(v)", c.Type)
+	}
+
+	switch {
+	case c.Encode == "" && ok:
+		c.Encode = known.encode
+	case c.Encode == "" && c.Type == c.Storage:
+		c.Encode = "v"
+	case c.Encode == "":
+		c.Encode = fmt.Sprintf("%s(v)", c.Storage)
+	}
+
+	switch {
+	case c.Sample == "" && ok:
+		c.Sample = known.sample
+	case c.Sample == "" && c.Storage == "string":
+		c.Sample = `"hello"`
+	case c.Sample == "" && c.Storage == "bool":
+		c.Sample = "true"
+	case c.Sample == "":
+		c.Sample = "42"
+	}
+
+	switch {
+	case c.Record == "" && ok && known.record != "":
+		c.Record = known.record
+	case c.Record == "":
+		c.Record = c.Type
+	}
+
+	if c.Index != nil && (c.Index.Name == "" || c.Index.Predicate == "") {
+		return fmt.Errorf("index must have a name and a predicate")
+	}
+	return nil
+}
+
+// isIdentifier returns whether the name is a valid lower-case identifier
+func isIdentifier(name string) bool {
+	for i, r := range name {
+		if !unicode.IsLower(r) && r != '_' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
@@ -1,37 +1,81 @@
 package entity
 
 import (
+	"bufio"
+	"fmt"
+	"io"
 	"os"
 	"path"
+	"reflect"
+	"sync"
 
 	"github.com/kelindar/column"
+	"github.com/kelindar/column/commit"
+	"github.com/kelindar/ecs/internal/atomicfile"
 	"github.com/rs/xid"
 )
 
 // Collection represents a collection of mobile objects
 type Collection[T any] struct {
 	*column.Collection
-	name string
-	read func(*column.Txn) T
+	name      string
+	version   uint32
+	read      func(*column.Txn) T
+	hooks     hooks[T]
+	feed      *feed
+	journal   *journal
+	columns   column.Object      // The columns of the collection, by a value of their type
+	logErrors []func(error)      // The callbacks for the errors of the write-ahead log
+	deleted   *column.Collection // The deleted entities, until the delete hooks are fired
+	burying   sync.Mutex         // Lock held while firing the delete hooks
 }
 
-// NewCollection creates a new mobile object collection
-func NewCollection[T any](name string, read func(txn *column.Txn) T) *Collection[T] {
-	db := column.NewCollection()
+// NewCollection creates a new mobile object collection. The version is the version
+// of its schema, written along with every snapshot of the collection.
+func NewCollection[T any](name string, version uint32, read func(txn *column.Txn) T) *Collection[T] {
+	feed := new(feed)
+	db := column.NewCollection(column.Options{Writer: feed})
 	db.CreateColumn("id", column.ForKey()) // Unique ID
+	deleted := column.NewCollection()
+	deleted.CreateColumn("id", column.ForKey())
 	return &Collection[T]{
 		Collection: db,
 		name:       name,
+		version:    version,
 		read:       read,
+		feed:       feed,
+		columns:    make(column.Object),
+		deleted:    deleted,
 	}
 }
 
-// Insert inserts a mobile into the collection
-func (c *Collection[T]) Insert(fn func(v T) error) error {
-	return c.Collection.Query(func(txn *column.Txn) error {
-		return txn.InsertKey(xid.New().String(), func(r column.Row) error {
+// CreateColumn creates a column of the specified type and adds it to the collection
+func (c *Collection[T]) CreateColumn(name string, col column.Column) error {
+	if err := c.Collection.CreateColumn(name, col); err != nil {
+		return err
+	}
+
+	if zero, ok := zeroOf(col); ok {
+		c.columns[name] = zero
+		kind, _ := column.ForKind(reflect.TypeOf(zero).Kind())
+		return c.deleted.CreateColumn(name, kind)
+	}
+	return nil
+}
+
+// Insert inserts a mobile into the collection and returns its unique identifier
+func (c *Collection[T]) Insert(fn func(v T) error) (string, error) {
+	key := xid.New().String()
+	if err := c.Collection.Query(func(txn *column.Txn) error {
+		return txn.InsertKey(key, func(r column.Row) error {
 			return fn(c.read(txn))
 		})
+	}); err != nil {
+		return "", err
+	}
+
+	return key, c.notify(&c.hooks.insert, func(txn *column.Txn, fn func(column.Row) error) error {
+		return txn.QueryKey(key, fn)
 	})
 }
 
@@ -45,24 +89,316 @@ func (c *Collection[T]) Range(fn func(v T), filters ...string) error {
 	})
 }
 
+// RangeIndexed iterates over all rows that match the specified filter columns,
+// along with their index in the collection.
+func (c *Collection[T]) RangeIndexed(fn func(idx uint32, v T), filters ...string) error {
+	return c.Collection.Query(func(txn *column.Txn) error {
+		cursor := c.read(txn)
+		return txn.With(filters...).Range(func(idx uint32) {
+			fn(idx, cursor)
+		})
+	})
+}
+
 // UpdateAt updates a mobile at a given index
 func (c *Collection[T]) UpdateAt(idx uint32, fn func(v T) error) error {
-	return c.Query(func(txn *column.Txn) error {
+	if err := c.Query(func(txn *column.Txn) error {
 		return txn.QueryAt(idx, func(r column.Row) error {
 			return fn(c.read(txn))
 		})
+	}); err != nil {
+		return err
+	}
+
+	return c.notify(&c.hooks.update, func(txn *column.Txn, fn func(column.Row) error) error {
+		return txn.QueryAt(idx, fn)
+	})
+}
+
+// ---------------------------------- Lookup ----------------------------------
+
+// Get reads an entity with the specified unique identifier
+func (c *Collection[T]) Get(id string, fn func(v T) error) error {
+	return c.Collection.Query(func(txn *column.Txn) error {
+		return txn.QueryKey(id, func(r column.Row) error {
+			return fn(c.read(txn))
+		})
+	})
+}
+
+// UpdateByID updates an entity with the specified unique identifier
+func (c *Collection[T]) UpdateByID(id string, fn func(v T) error) error {
+	if err := c.Get(id, fn); err != nil {
+		return err
+	}
+
+	return c.notify(&c.hooks.update, func(txn *column.Txn, fn func(column.Row) error) error {
+		return txn.QueryKey(id, fn)
+	})
+}
+
+// Upsert inserts an entity with the specified unique identifier, or updates it if
+// it already exists.
+func (c *Collection[T]) Upsert(id string, fn func(v T) error) error {
+	var inserted bool
+	if err := c.Collection.Query(func(txn *column.Txn) error {
+		key := txn.Key()
+		return txn.UpsertKey(id, func(r column.Row) error {
+			_, exists := key.Get() // the key of a new row is only set once committed
+			inserted = !exists
+			return fn(c.read(txn))
+		})
+	}); err != nil {
+		return err
+	}
+
+	callbacks := &c.hooks.update
+	if inserted {
+		callbacks = &c.hooks.insert
+	}
+
+	return c.notify(callbacks, func(txn *column.Txn, fn func(column.Row) error) error {
+		return txn.QueryKey(id, fn)
 	})
 }
 
+// Exists returns whether an entity with the specified unique identifier exists
+func (c *Collection[T]) Exists(id string) bool {
+	return c.Collection.QueryKey(id, func(r column.Row) error {
+		return nil
+	}) == nil
+}
+
+// ---------------------------------- Delete ----------------------------------
+
+// Delete deletes an entity with the specified unique identifier
+func (c *Collection[T]) Delete(id string) error {
+	var deleted []column.Object
+	if err := c.Collection.Query(func(txn *column.Txn) error {
+		if err := txn.DeleteKey(id); err != nil {
+			return err
+		}
+
+		// The deleted row can still be read until the transaction is committed
+		return txn.QueryKey(id, func(r column.Row) error {
+			deleted = c.capture(txn, deleted)
+			return nil
+		})
+	}); err != nil {
+		return err
+	}
+
+	return c.bury(deleted)
+}
+
+// DeleteAt deletes an entity at a given index and returns whether it existed
+func (c *Collection[T]) DeleteAt(idx uint32) (deleted bool) {
+	var entities []column.Object
+	if err := c.Collection.Query(func(txn *column.Txn) error {
+		if !txn.DeleteAt(idx) {
+			return nil
+		}
+
+		return txn.QueryAt(idx, func(r column.Row) error {
+			entities = c.capture(txn, entities)
+			deleted = true
+			return nil
+		})
+	}); err != nil {
+		return false
+	}
+
+	return deleted && c.bury(entities) == nil
+}
+
+// DeleteWhere deletes all of the entities that match the specified filter columns
+// and returns the number of deleted entities.
+func (c *Collection[T]) DeleteWhere(filters ...string) (count int, err error) {
+	var deleted []column.Object
+	if err = c.Collection.Query(func(txn *column.Txn) error {
+		return txn.With(filters...).Range(func(idx uint32) {
+			deleted = c.capture(txn, deleted)
+			txn.DeleteAt(idx)
+			count++
+		})
+	}); err != nil {
+		return 0, err
+	}
+
+	return count, c.bury(deleted)
+}
+
+// capture appends the values of the entity at the cursor of the transaction, if
+// there are delete hooks to fire once the deletion is committed.
+func (c *Collection[T]) capture(txn *column.Txn, deleted []column.Object) []column.Object {
+	if c.hooks.empty(&c.hooks.delete) {
+		return deleted
+	}
+
+	id, _ := txn.Key().Get()
+	entity := column.Object{"id": id}
+	for name := range c.columns {
+		if v, ok := txn.Any(name).Get(); ok {
+			entity[name] = v
+		}
+	}
+	return append(deleted, entity)
+}
+
+// bury fires the delete hooks with the entities captured before their deletion was
+// committed. Since they can no longer be read from the collection, they are read
+// from a scratch collection of the same columns, which is emptied right after.
+func (c *Collection[T]) bury(deleted []column.Object) error {
+	if len(deleted) == 0 {
+		return nil
+	}
+
+	c.burying.Lock()
+	defer c.burying.Unlock()
+	if err := c.deleted.Query(func(txn *column.Txn) error {
+		for _, entity := range deleted {
+			if err := txn.InsertKey(entity["id"].(string), func(r column.Row) error {
+				for name, v := range entity {
+					if name != "id" {
+						r.SetAny(name, v)
+					}
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return c.deleted.Query(func(txn *column.Txn) error {
+		cursor := c.read(txn)
+		txn.DeleteAll()
+		return txn.Range(func(idx uint32) {
+			c.hooks.fire(&c.hooks.delete, cursor)
+		})
+	})
+}
+
+// ---------------------------------- Hooks ----------------------------------
+
+// OnInsert registers a callback which is invoked after an entity is inserted.
+func (c *Collection[T]) OnInsert(fn func(v T)) {
+	c.hooks.subscribe(&c.hooks.insert, fn)
+}
+
+// OnUpdate registers a callback which is invoked after an entity is updated with
+// UpdateAt or UpdateByID. Changes done while iterating with Range are not notified.
+func (c *Collection[T]) OnUpdate(fn func(v T)) {
+	c.hooks.subscribe(&c.hooks.update, fn)
+}
+
+// OnDelete registers a callback which is invoked after an entity is deleted, with
+// the components it had right before being deleted.
+func (c *Collection[T]) OnDelete(fn func(v T)) {
+	c.hooks.subscribe(&c.hooks.delete, fn)
+}
+
+// OnCommit registers a callback which is invoked with every change committed to
+// the collection, chunk by chunk, including the changes done while iterating with
+// Range. The commit is only valid for the duration of the callback, which is
+// invoked while the chunk is locked and must not access the collection.
+func (c *Collection[T]) OnCommit(fn func(commit.Commit)) {
+	c.feed.subscribe(fn)
+}
+
+// notify invokes the callbacks with the entity selected by the query function,
+// if there are any callbacks registered.
+func (c *Collection[T]) notify(callbacks *[]func(T), query func(*column.Txn, func(column.Row) error) error) error {
+	if c.hooks.empty(callbacks) {
+		return nil
+	}
+
+	return c.Collection.Query(func(txn *column.Txn) error {
+		return query(txn, func(r column.Row) error {
+			c.hooks.fire(callbacks, c.read(txn))
+			return nil
+		})
+	})
+}
+
+// hooks represents a set of callbacks for the lifecycle of the entities. The
+// callbacks are invoked within a transaction, hence they must not modify the
+// collection they are registered on.
+type hooks[T any] struct {
+	lock   sync.RWMutex
+	insert []func(T)
+	update []func(T)
+	delete []func(T)
+}
+
+// subscribe adds a callback to the list
+func (h *hooks[T]) subscribe(callbacks *[]func(T), fn func(T)) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	*callbacks = append(*callbacks, fn)
+}
+
+// empty returns whether the list of callbacks is empty
+func (h *hooks[T]) empty(callbacks *[]func(T)) bool {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	return len(*callbacks) == 0
+}
+
+// fire invokes all of the callbacks of the list
+func (h *hooks[T]) fire(callbacks *[]func(T), v T) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	for _, fn := range *callbacks {
+		fn(v)
+	}
+}
+
+// feed represents a commit logger that forwards the commits to its subscribers
+type feed struct {
+	lock      sync.RWMutex
+	callbacks []func(commit.Commit)
+}
+
+// subscribe adds a callback to the feed
+func (f *feed) subscribe(fn func(commit.Commit)) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.callbacks = append(f.callbacks, fn)
+}
+
+// Append forwards a commit to all of the callbacks
+func (f *feed) Append(commit commit.Commit) error {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	for _, fn := range f.callbacks {
+		fn(commit)
+	}
+	return nil
+}
+
 // ---------------------------------- Load/Save ----------------------------------
 
+// Version returns the version of the schema of the collection
+func (c *Collection[T]) Version() uint32 {
+	return c.version
+}
+
 // Restore restores the collection from the specified directory. This operation
 // should be called before any of transactions, right after initialization. If
 // the file does not exist, it creates an empty collection and saves it.
-func (c *Collection[T]) Restore(dir string) error {
-	filename := path.Join(dir, c.name)
+func (c *Collection[T]) Restore(dir string, migrations ...Migration) error {
+	return c.RestoreFile(path.Join(dir, c.name), migrations...)
+}
+
+// RestoreFile restores the collection from the specified file, creating an empty
+// collection file if it does not exist.
+func (c *Collection[T]) RestoreFile(filename string, migrations ...Migration) error {
 	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		return c.Snapshot(dir)
+		return c.SnapshotFile(filename)
 	}
 
 	// Otherwise, attempt to open the file and restore
@@ -72,22 +408,44 @@ func (c *Collection[T]) Restore(dir string) error {
 	}
 
 	defer file.Close()
-	return c.Collection.Restore(file)
+	return c.RestoreFrom(file, migrations...)
 }
 
-// Snapshot writes a collection snapshot into the specified directory.
-func (c *Collection[T]) Snapshot(dir string) error {
-	filename := path.Join(dir, c.name)
-	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+// RestoreFrom restores the collection from a snapshot written by SnapshotTo. If
+// the snapshot was written with an older version of the schema, it is upgraded
+// with the migrations, one version at a time.
+func (c *Collection[T]) RestoreFrom(src io.Reader, migrations ...Migration) error {
+	reader := bufio.NewReader(src)
+	version, err := readHeader(reader)
+	switch {
+	case err != nil:
 		return err
+	case version == c.version:
+		return c.Collection.Restore(reader)
+	case version > c.version:
+		return fmt.Errorf("entity: unable to restore %s, saved with a newer version %d", c.name, version)
+	default:
+		return c.migrate(reader, version, migrations)
 	}
+}
 
-	file, err := os.Create(filename)
-	if err != nil {
+// SnapshotTo writes a collection snapshot, along with the version of its schema
+func (c *Collection[T]) SnapshotTo(dst io.Writer) error {
+	if err := writeHeader(dst, c.version); err != nil {
 		return err
 	}
 
-	// Create a new snapshot of an empty collection
-	defer file.Close()
-	return c.Collection.Snapshot(file)
+	return c.Collection.Snapshot(dst)
+}
+
+// Snapshot writes a collection snapshot into the specified directory.
+func (c *Collection[T]) Snapshot(dir string) error {
+	return c.SnapshotFile(path.Join(dir, c.name))
+}
+
+// SnapshotFile atomically writes a collection snapshot into the specified file,
+// creating its directory if necessary. The previous snapshot is only replaced
+// once the new one is fully written and synced to the disk.
+func (c *Collection[T]) SnapshotFile(filename string) error {
+	return atomicfile.WriteFile(filename, c.SnapshotTo)
 }
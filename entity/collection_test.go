@@ -4,20 +4,22 @@ import (
 	"testing"
 
 	"github.com/kelindar/column"
+	"github.com/kelindar/column/commit"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestCollection(t *testing.T) {
-	c := NewCollection("test", cursorFor)
+	c := NewCollection("test", 1, cursorFor)
 	c.CreateColumn("msg", column.ForString())
 	assert.NotNil(t, c)
 
 	// Insert
-	err := c.Insert(func(v Object) error {
+	id, err := c.Insert(func(v Object) error {
 		v.SetMessage("hello")
 		return nil
 	})
 	assert.NoError(t, err)
+	assert.NotEmpty(t, id)
 	assert.Equal(t, 1, c.Count())
 
 	// Update
@@ -28,10 +30,207 @@ func TestCollection(t *testing.T) {
 
 	// Range
 	assert.NoError(t, c.Range(func(v Object) {
-		assert.NotEmpty(t, v.ID())
+		assert.Equal(t, id, v.ID())
 		assert.Equal(t, v.Message(), "hi")
 	}))
 
+	// Range with the index
+	assert.NoError(t, c.RangeIndexed(func(idx uint32, v Object) {
+		assert.Equal(t, uint32(0), idx)
+		assert.Equal(t, id, v.ID())
+	}))
+}
+
+func TestLookup(t *testing.T) {
+	c := NewCollection("test", 1, cursorFor)
+	c.CreateColumn("msg", column.ForString())
+
+	var updated []string
+	c.OnUpdate(func(v Object) {
+		updated = append(updated, v.Message())
+	})
+
+	id, err := c.Insert(func(v Object) error {
+		v.SetMessage("hello")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, c.Exists(id))
+	assert.False(t, c.Exists("unknown"))
+
+	// Update by ID
+	assert.NoError(t, c.UpdateByID(id, func(v Object) error {
+		v.SetMessage("hi")
+		return nil
+	}))
+	assert.Error(t, c.UpdateByID("unknown", func(v Object) error {
+		return nil
+	}))
+
+	// Get by ID
+	assert.NoError(t, c.Get(id, func(v Object) error {
+		assert.Equal(t, id, v.ID())
+		assert.Equal(t, "hi", v.Message())
+		return nil
+	}))
+	assert.Error(t, c.Get("unknown", func(v Object) error {
+		return nil
+	}))
+	assert.Equal(t, []string{"hi"}, updated)
+}
+
+func TestUpsert(t *testing.T) {
+	c := NewCollection("test", 1, cursorFor)
+	c.CreateColumn("msg", column.ForString())
+
+	var inserted, updated []string
+	c.OnInsert(func(v Object) {
+		inserted = append(inserted, v.Message())
+	})
+	c.OnUpdate(func(v Object) {
+		updated = append(updated, v.Message())
+	})
+
+	for _, msg := range []string{"hello", "hi"} {
+		assert.NoError(t, c.Upsert("npc", func(v Object) error {
+			v.SetMessage(msg)
+			return nil
+		}))
+	}
+
+	assert.Equal(t, 1, c.Count())
+	assert.Equal(t, []string{"hello"}, inserted)
+	assert.Equal(t, []string{"hi"}, updated)
+	assert.NoError(t, c.Get("npc", func(v Object) error {
+		assert.Equal(t, "hi", v.Message())
+		return nil
+	}))
+
+	// Once deleted, the entity is inserted again
+	assert.NoError(t, c.Delete("npc"))
+	assert.NoError(t, c.Upsert("npc", func(v Object) error {
+		v.SetMessage("back")
+		return nil
+	}))
+	assert.Equal(t, []string{"hello", "back"}, inserted)
+	assert.Equal(t, []string{"hi"}, updated)
+}
+
+func TestDelete(t *testing.T) {
+	c := NewCollection("test", 1, cursorFor)
+	c.CreateColumn("msg", column.ForString())
+	c.CreateIndex("hello", "msg", func(r column.Reader) bool {
+		return r.String() == "hello"
+	})
+
+	var deleted []string
+	c.OnDelete(func(v Object) {
+		deleted = append(deleted, v.Message())
+	})
+
+	for _, msg := range []string{"a", "b", "hello", "hello"} {
+		_, err := c.Insert(func(v Object) error {
+			v.SetMessage(msg)
+			return nil
+		})
+		assert.NoError(t, err)
+	}
+
+	// Delete by index
+	assert.True(t, c.DeleteAt(0))
+	assert.False(t, c.DeleteAt(0))
+	assert.Equal(t, 3, c.Count())
+
+	// Delete by filter
+	count, err := c.DeleteWhere("hello")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, 1, c.Count())
+
+	// Delete by ID
+	var id string
+	assert.NoError(t, c.Range(func(v Object) {
+		id = v.ID()
+	}))
+	assert.NoError(t, c.Delete(id))
+	assert.Error(t, c.Delete(id))
+	assert.Equal(t, 0, c.Count())
+	assert.Equal(t, []string{"a", "hello", "hello", "b"}, deleted)
+}
+
+func TestDeleteCommitted(t *testing.T) {
+	c := NewCollection("test", 1, cursorFor)
+	c.CreateColumn("msg", column.ForString())
+
+	// The hook is fired once the entity can no longer be found
+	var deleted []string
+	c.OnDelete(func(v Object) {
+		assert.False(t, c.Exists(v.ID()))
+		deleted = append(deleted, v.ID()+":"+v.Message())
+	})
+
+	for _, msg := range []string{"a", "b"} {
+		assert.NoError(t, c.Upsert("npc", func(v Object) error {
+			v.SetMessage(msg)
+			return nil
+		}))
+		assert.NoError(t, c.Delete("npc"))
+	}
+
+	assert.Equal(t, []string{"npc:a", "npc:b"}, deleted)
+}
+
+func TestHooks(t *testing.T) {
+	c := NewCollection("test", 1, cursorFor)
+	c.CreateColumn("msg", column.ForString())
+
+	var inserted, updated []string
+	c.OnInsert(func(v Object) {
+		inserted = append(inserted, v.Message())
+	})
+	c.OnUpdate(func(v Object) {
+		updated = append(updated, v.Message())
+	})
+
+	_, err := c.Insert(func(v Object) error {
+		v.SetMessage("hello")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, c.UpdateAt(0, func(v Object) error {
+		v.SetMessage("hi")
+		return nil
+	}))
+
+	assert.Equal(t, []string{"hello"}, inserted)
+	assert.Equal(t, []string{"hi"}, updated)
+}
+
+func TestOnCommit(t *testing.T) {
+	c := NewCollection("test", 1, cursorFor)
+	c.CreateColumn("msg", column.ForString())
+
+	var commits int
+	var changes []string
+	c.OnCommit(func(v commit.Commit) {
+		commits++
+		for _, u := range v.Updates {
+			changes = append(changes, u.Column)
+		}
+	})
+
+	_, err := c.Insert(func(v Object) error {
+		v.SetMessage("hello")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Range(func(v Object) {
+		v.SetMessage("hi")
+	}))
+
+	assert.Contains(t, changes, "row")
+	assert.Contains(t, changes, "msg")
+	assert.Equal(t, 2, commits)
 }
 
 // ---------------------------------- Test object ----------------------------------
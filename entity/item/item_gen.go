@@ -0,0 +1,102 @@
+// Code generated by entitygen; DO NOT EDIT.
+
+package item
+
+import (
+	"github.com/kelindar/column"
+	"github.com/kelindar/ecs/entity"
+	"github.com/kelindar/ecs/state"
+	"github.com/kelindar/tile"
+)
+
+// Collection represents a collection of items
+type Collection = entity.Collection[Item]
+
+// NewCollection creates a new item collection
+func NewCollection() *Collection {
+	db := entity.NewCollection("items.bin", 1, fromTxn)
+	db.CreateColumn("img", column.ForUint32()) // Image index
+	db.CreateColumn("at", column.ForUint32())  // Location as packed tile.Point
+	return db
+}
+
+// Item represents a view on a current row
+type Item struct {
+	id interface {
+		Get() (string, bool)
+	}
+	img interface {
+		Get() (uint32, bool)
+		Set(value uint32)
+	}
+	at interface {
+		Get() (uint32, bool)
+		Set(value uint32)
+	}
+}
+
+// fromTxn creates a statically-typed mapping for a transaction
+func fromTxn(txn *column.Txn) Item {
+	return Item{
+		id:  txn.Key(),
+		img: txn.Uint32("img"),
+		at:  txn.Uint32("at"),
+	}
+}
+
+// ID returns the unique identifier of the item
+func (e *Item) ID() string {
+	v, _ := e.id.Get()
+	return v
+}
+
+// ---------------------------------- Image ----------------------------------
+
+// Image reads the image index
+func (e *Item) Image() uint32 {
+	v, _ := e.img.Get()
+	return v
+}
+
+// SetImage writes the image index
+func (e *Item) SetImage(v uint32) {
+	e.img.Set(v)
+}
+
+// ---------------------------------- Location ----------------------------------
+
+// Location reads the current location
+func (e *Item) Location() tile.Point {
+	v, _ := e.at.Get()
+	return tile.At(int16(v>>16), int16(v))
+}
+
+// SetLocation writes the current location
+func (e *Item) SetLocation(v tile.Point) {
+	e.at.Set(v.Integer())
+}
+
+// ---------------------------------- Record ----------------------------------
+
+// Record represents a item as a plain record, keyed by its unique identifier
+// and with its components decoded, which can be encoded in JSON.
+type Record struct {
+	ID       string      `json:"id"`
+	Image    uint32      `json:"img"`
+	Location state.Point `json:"at"`
+}
+
+// Record reads all of the components into a record
+func (e *Item) Record() Record {
+	return Record{
+		ID:       e.ID(),
+		Image:    e.Image(),
+		Location: state.Point(e.Location()),
+	}
+}
+
+// SetRecord writes all of the components from a record, except for the identifier
+func (e *Item) SetRecord(r Record) {
+	e.SetImage(r.Image)
+	e.SetLocation(tile.Point(r.Location))
+}
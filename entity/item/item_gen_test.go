@@ -0,0 +1,51 @@
+// Code generated by entitygen; DO NOT EDIT.
+
+package item
+
+import (
+	"testing"
+
+	"github.com/kelindar/tile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratedItem(t *testing.T) {
+	var wantImage uint32 = 42
+	var wantLocation tile.Point = tile.At(1, 2)
+
+	c := NewCollection()
+	id, err := c.Insert(func(v Item) error {
+		v.SetImage(wantImage)
+		v.SetLocation(wantLocation)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, c.Count())
+
+	// Read back every component
+	assert.NoError(t, c.Get(id, func(v Item) error {
+		assert.Equal(t, id, v.ID())
+		assert.Equal(t, wantImage, v.Image())
+		assert.Equal(t, wantLocation, v.Location())
+		return nil
+	}))
+
+	// Copy the components through a record
+	var record Record
+	assert.NoError(t, c.Get(id, func(v Item) error {
+		record = v.Record()
+		return nil
+	}))
+	assert.Equal(t, id, record.ID)
+
+	clone, err := c.Insert(func(v Item) error {
+		v.SetRecord(record)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Get(clone, func(v Item) error {
+		assert.Equal(t, wantImage, v.Image())
+		assert.Equal(t, wantLocation, v.Location())
+		return nil
+	}))
+}
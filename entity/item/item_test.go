@@ -12,7 +12,7 @@ func TestItem(t *testing.T) {
 	assert.NotNil(t, c)
 
 	// Insert
-	err := c.Insert(func(v Item) error {
+	_, err := c.Insert(func(v Item) error {
 		v.SetLocation(tile.At(1, 1))
 		return nil
 	})
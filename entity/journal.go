@@ -0,0 +1,256 @@
+package entity
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kelindar/column/commit"
+	"github.com/klauspost/compress/s2"
+	"go.uber.org/multierr"
+)
+
+// journal represents a write-ahead log of the commits of a collection, split into
+// numbered segments. A new segment is started before every snapshot, so that the
+// segments which precede it can be removed once the snapshot is durably saved.
+type journal struct {
+	lock    sync.Mutex
+	path    string        // The path of the log, suffixed with the segment number
+	version uint32        // The version of the schema, written at the start of every segment
+	segment uint64        // The number of the current segment
+	log     *commit.Log   // The current segment, nil when closed
+	err     error         // The first error which occurred while appending
+	report  []func(error) // The callbacks for the errors which occur while appending
+}
+
+// OpenLog replays the write-ahead log at the specified path on top of the
+// collection, then appends every subsequent commit to a new segment of the log.
+// This should be called right after restoring the last snapshot. Replaying a
+// commit that the snapshot already contains has no effect, as long as the
+// columns are only ever set and never incremented. Every segment starts with the
+// version of the schema it was logged with, and the commits logged with another
+// version cannot be replayed, since they are not migrated.
+func (c *Collection[T]) OpenLog(filename string) error {
+	segments, err := segmentsOf(filename)
+	if err != nil {
+		return err
+	}
+
+	// Replay all of the segments in order, before logging anything
+	for _, segment := range segments {
+		if err := c.replay(segmentName(filename, segment)); err != nil {
+			return err
+		}
+	}
+
+	j := &journal{path: filename, version: c.version, report: c.logErrors}
+	if len(segments) > 0 {
+		j.segment = segments[len(segments)-1]
+	}
+
+	if err := j.rotate(); err != nil {
+		return err
+	}
+
+	c.journal = j
+	c.feed.subscribe(j.append)
+	return nil
+}
+
+// OnLogError registers a callback which is invoked with every error that occurs
+// while appending a commit to the write-ahead log. The callback is invoked while
+// the commit is written and must not access the collection. This must be called
+// before OpenLog.
+func (c *Collection[T]) OnLogError(fn func(err error)) {
+	c.logErrors = append(c.logErrors, fn)
+}
+
+// RotateLog starts a new segment of the write-ahead log and returns its number.
+// This must be called before taking a snapshot, all of the segments that precede
+// the returned one can then be truncated once the snapshot is saved.
+func (c *Collection[T]) RotateLog() (uint64, error) {
+	if c.journal == nil {
+		return 0, nil
+	}
+
+	c.journal.lock.Lock()
+	defer c.journal.lock.Unlock()
+	if err := c.journal.rotate(); err != nil {
+		return 0, err
+	}
+
+	return c.journal.segment, nil
+}
+
+// TruncateLog removes the segments of the write-ahead log that precede the
+// specified one, which must only be done once the snapshot is durably saved.
+func (c *Collection[T]) TruncateLog(before uint64) error {
+	if c.journal == nil {
+		return nil
+	}
+
+	segments, err := segmentsOf(c.journal.path)
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		if segment < before {
+			if err := os.Remove(segmentName(c.journal.path, segment)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// CloseLog stops logging the commits and closes the write-ahead log. It returns
+// the first error which occurred while appending to the log, if any.
+func (c *Collection[T]) CloseLog() error {
+	if c.journal == nil {
+		return nil
+	}
+
+	c.journal.lock.Lock()
+	defer c.journal.lock.Unlock()
+	err := c.journal.err
+	if c.journal.log != nil {
+		err = multierr.Append(err, c.journal.log.Close())
+		c.journal.log = nil
+	}
+	return err
+}
+
+// replay replays all of the commits of a segment. A commit which was only partially
+// written, because the process has crashed while appending it, is discarded.
+func (c *Collection[T]) replay(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+	version, err := readSegmentHeader(file)
+	switch {
+	case errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF):
+		return nil // crashed before anything was logged
+	case err != nil:
+		return err
+	}
+
+	err = commit.Open(file).Range(func(change commit.Commit) error {
+		if version != c.version {
+			return fmt.Errorf("entity: unable to replay %s, logged with version %d of the schema instead of %d",
+				filename, version, c.version)
+		}
+		return c.Collection.Replay(change)
+	})
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, s2.ErrCorrupt) {
+		return nil
+	}
+	return err
+}
+
+// append appends a commit to the current segment
+func (j *journal) append(change commit.Commit) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if j.log == nil {
+		return
+	}
+
+	if err := j.log.Append(change); err != nil {
+		if j.err == nil {
+			j.err = err
+		}
+
+		for _, fn := range j.report {
+			fn(err)
+		}
+	}
+}
+
+// rotate closes the current segment and starts the next one, must be called while
+// the journal is locked or before it's subscribed to the commits.
+func (j *journal) rotate() error {
+	if j.log != nil {
+		if err := j.log.Close(); err != nil {
+			return err
+		}
+		j.log = nil
+	}
+
+	file, err := os.OpenFile(segmentName(j.path, j.segment+1), os.O_RDWR|os.O_CREATE|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	if err := writeHeader(file, j.version); err != nil {
+		return multierr.Append(err, file.Close())
+	}
+
+	j.segment++
+	j.log = commit.Open(file)
+	return nil
+}
+
+// segmentName returns the file name of a segment of the log
+func segmentName(filename string, segment uint64) string {
+	return fmt.Sprintf("%s.%d", filename, segment)
+}
+
+// segmentsOf returns the numbers of the existing segments of the log, in order
+func segmentsOf(filename string) ([]uint64, error) {
+	matches, err := filepath.Glob(filename + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]uint64, 0, len(matches))
+	for _, match := range matches {
+		if segment, err := strconv.ParseUint(strings.TrimPrefix(match, filename+"."), 10, 64); err == nil {
+			segments = append(segments, segment)
+		}
+	}
+
+	slices.Sort(segments)
+	return segments, nil
+}
+
+// readSegmentHeader reads the version of the schema of a segment, without reading
+// past its header. Segments written before the header was introduced are
+// considered to be version 1.
+func readSegmentHeader(file *os.File) (uint32, error) {
+	prefix := make([]byte, len(magic))
+	if _, err := io.ReadFull(file, prefix); err != nil {
+		return 0, err
+	}
+
+	if !bytes.Equal(prefix, magic) {
+		_, err := file.Seek(0, io.SeekStart)
+		return 1, err
+	}
+
+	version, err := binary.ReadUvarint(byteReader{file})
+	return uint32(version), err
+}
+
+// byteReader reads one byte at a time from the underlying reader
+type byteReader struct {
+	io.Reader
+}
+
+// ReadByte reads a single byte
+func (r byteReader) ReadByte() (byte, error) {
+	var b [1]byte
+	_, err := io.ReadFull(r.Reader, b[:])
+	return b[0], err
+}
@@ -0,0 +1,138 @@
+package entity
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kelindar/column"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournal(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.log")
+	c := newLogged(t, filename)
+	id, err := c.Insert(func(v Object) error {
+		v.SetMessage("hello")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, c.UpdateByID(id, func(v Object) error {
+		v.SetMessage("hi")
+		return nil
+	}))
+	assert.NoError(t, c.CloseLog())
+
+	// Replay the log on top of an empty collection
+	out := newLogged(t, filename)
+	defer out.CloseLog()
+	assert.Equal(t, 1, out.Count())
+	assert.NoError(t, out.Get(id, func(v Object) error {
+		assert.Equal(t, "hi", v.Message())
+		return nil
+	}))
+}
+
+func TestJournalTruncate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.log")
+	c := newLogged(t, filename)
+	first, _ := c.Insert(func(v Object) error { return nil })
+
+	// Take a snapshot, then truncate the log that precedes it
+	segment, err := c.RotateLog()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), segment)
+
+	var snapshot bytes.Buffer
+	assert.NoError(t, c.SnapshotTo(&snapshot))
+	assert.NoError(t, c.TruncateLog(segment))
+	assert.NoFileExists(t, filename+".1")
+
+	// Changes after the snapshot are only in the log
+	second, _ := c.Insert(func(v Object) error { return nil })
+	assert.NoError(t, c.DeleteKey(first))
+	assert.NoError(t, c.CloseLog())
+
+	out := NewCollection("test", 1, cursorFor)
+	out.CreateColumn("msg", column.ForString())
+	assert.NoError(t, out.RestoreFrom(&snapshot))
+	assert.NoError(t, out.OpenLog(filename))
+	defer out.CloseLog()
+
+	assert.Equal(t, 1, out.Count())
+	assert.False(t, out.Exists(first))
+	assert.True(t, out.Exists(second))
+}
+
+func TestJournalTorn(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.log")
+	c := newLogged(t, filename)
+	for _, msg := range []string{"a", "b"} {
+		c.Insert(func(v Object) error {
+			v.SetMessage(msg)
+			return nil
+		})
+	}
+	assert.NoError(t, c.CloseLog())
+
+	// Crash in the middle of appending the last commit
+	info, err := os.Stat(filename + ".1")
+	assert.NoError(t, err)
+	assert.NoError(t, os.Truncate(filename+".1", info.Size()-3))
+
+	out := newLogged(t, filename)
+	defer out.CloseLog()
+	assert.Equal(t, 1, out.Count())
+	assert.FileExists(t, filename+".2")
+}
+
+// newLogged creates a collection and opens its write-ahead log
+func newLogged(t *testing.T, filename string) *Collection[Object] {
+	c := NewCollection("test", 1, cursorFor)
+	c.CreateColumn("msg", column.ForString())
+	assert.NoError(t, c.OpenLog(filename))
+	return c
+}
+
+func TestJournalVersion(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.log")
+	c := newLogged(t, filename)
+	c.Insert(func(v Object) error { return nil })
+	assert.NoError(t, c.CloseLog())
+
+	// Commits logged with another version of the schema are refused
+	out := NewCollection("test", 2, cursorFor)
+	out.CreateColumn("msg", column.ForString())
+	assert.ErrorContains(t, out.OpenLog(filename), "version 1 of the schema instead of 2")
+}
+
+func TestJournalVersionEmpty(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.log")
+	c := newLogged(t, filename)
+	assert.NoError(t, c.CloseLog())
+
+	// A segment without any commits can be opened with any version
+	out := NewCollection("test", 2, cursorFor)
+	out.CreateColumn("msg", column.ForString())
+	assert.NoError(t, out.OpenLog(filename))
+	assert.NoError(t, out.CloseLog())
+}
+
+func TestJournalError(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "test.log")
+	c := NewCollection("test", 1, cursorFor)
+	c.CreateColumn("msg", column.ForString())
+
+	var reported []error
+	c.OnLogError(func(err error) {
+		reported = append(reported, err)
+	})
+	assert.NoError(t, c.OpenLog(filename))
+
+	// Fail every subsequent append by closing the segment underneath the journal
+	assert.NoError(t, c.journal.log.Close())
+	c.Insert(func(v Object) error { return nil })
+	assert.Len(t, reported, 1)
+	assert.Error(t, c.CloseLog())
+}
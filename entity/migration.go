@@ -0,0 +1,188 @@
+package entity
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+
+	"github.com/kelindar/column"
+	"github.com/kelindar/column/commit"
+)
+
+// magic marks the header of a snapshot, which is followed by the version of its schema.
+// Snapshots written before the header was introduced are considered to be version 1.
+var magic = []byte("ecs\x00")
+
+// Migration represents an upgrade of the snapshots of a collection from a version
+// of its schema to the next one. The rows of the snapshot are read with the schema
+// of that version, upgraded one version at a time, and then inserted into the
+// collection with its current schema. The schema only needs to list the columns
+// which have changed since, the others are read with their current type.
+type Migration struct {
+	From    uint32                        // The version upgraded from, to the next one
+	Schema  column.Object                 // The changed columns of that version, by a value of their type
+	Upgrade func(row column.Object) error // The function upgrading a row, keyed by column
+}
+
+// migrate restores the collection from a snapshot written with an older version of
+// its schema, by applying the migrations in order of their version.
+func (c *Collection[T]) migrate(src io.Reader, version uint32, migrations []Migration) error {
+	steps := make(map[uint32]Migration, len(migrations))
+	for _, m := range migrations {
+		steps[m.From] = m
+	}
+
+	// Make sure every version can be upgraded before reading anything
+	for v := version; v < c.version; v++ {
+		if _, ok := steps[v]; !ok {
+			return fmt.Errorf("entity: unable to restore %s, no migration from version %d", c.name, v)
+		}
+	}
+
+	// Read the columns which have not changed with their current type
+	schema := make(column.Object, len(c.columns))
+	maps.Copy(schema, steps[version].Schema)
+	for name, zero := range c.columns {
+		if _, ok := schema[name]; !ok {
+			schema[name] = zero
+		}
+	}
+
+	rows, err := readRows(src, schema)
+	if err != nil {
+		return fmt.Errorf("entity: unable to migrate %s from version %d, %w", c.name, version, err)
+	}
+
+	for v := version; v < c.version; v++ {
+		if steps[v].Upgrade == nil {
+			continue // only the columns have changed
+		}
+
+		for _, row := range rows {
+			if err := steps[v].Upgrade(row); err != nil {
+				return fmt.Errorf("entity: unable to migrate %s from version %d, %w", c.name, v, err)
+			}
+		}
+	}
+
+	// Insert the upgraded rows, their values must match the types of the columns
+	return c.Collection.Query(func(txn *column.Txn) error {
+		for _, row := range rows {
+			key, _ := row["id"].(string)
+			if err := txn.InsertKey(key, func(r column.Row) error {
+				for name, value := range row {
+					if name != "id" {
+						r.SetAny(name, value)
+					}
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// readRows restores a snapshot into a temporary collection with the specified
+// schema and reads all of its rows, including their unique identifier as "id". It
+// fails if the snapshot contains a column which is not in the schema, rather than
+// silently dropping it.
+func readRows(src io.Reader, schema column.Object) ([]column.Object, error) {
+	found := make(columnSet)
+	db := column.NewCollection(column.Options{Writer: found})
+	defer db.Close()
+
+	db.CreateColumn("id", column.ForKey())
+	if err := db.CreateColumnsOf(schema); err != nil {
+		return nil, err
+	}
+
+	if err := db.Restore(src); err != nil {
+		return nil, err
+	}
+
+	for name := range found {
+		if _, ok := schema[name]; !ok && name != "id" && name != "row" {
+			return nil, fmt.Errorf("column %q is missing from the schema", name)
+		}
+	}
+
+	var rows []column.Object
+	return rows, db.Query(func(txn *column.Txn) error {
+		key := txn.Key()
+		columns := make(map[string]interface{ Get() (any, bool) }, len(schema))
+		for name := range schema {
+			columns[name] = txn.Any(name)
+		}
+
+		return txn.Range(func(idx uint32) {
+			row := make(column.Object, len(columns)+1)
+			row["id"], _ = key.Get()
+			for name, col := range columns {
+				if v, ok := col.Get(); ok {
+					row[name] = v
+				}
+			}
+			rows = append(rows, row)
+		})
+	})
+}
+
+// columnSet represents a commit logger which collects the names of the columns
+// updated by the commits.
+type columnSet map[string]struct{}
+
+// Append adds the columns updated by the commit to the set
+func (s columnSet) Append(change commit.Commit) error {
+	for _, u := range change.Updates {
+		if !u.IsEmpty() {
+			s[u.Column] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// zeros maps the types of the columns to a value of their type
+var zeros = func() map[reflect.Type]any {
+	zeros := make(map[reflect.Type]any)
+	for _, zero := range []any{
+		int(0), int16(0), int32(0), int64(0), uint(0), uint16(0), uint32(0), uint64(0),
+		float32(0), float64(0), false, "",
+	} {
+		col, _ := column.ForKind(reflect.TypeOf(zero).Kind())
+		zeros[reflect.TypeOf(col)] = zero
+	}
+	return zeros
+}()
+
+// zeroOf returns a value of the type stored by the column, if it's a basic type
+func zeroOf(col column.Column) (any, bool) {
+	zero, ok := zeros[reflect.TypeOf(col)]
+	return zero, ok
+}
+
+// readHeader reads the version of the schema of a snapshot
+func readHeader(src *bufio.Reader) (uint32, error) {
+	if prefix, err := src.Peek(len(magic)); err != nil || !bytes.Equal(prefix, magic) {
+		return 1, nil // written before the header was introduced
+	}
+
+	src.Discard(len(magic))
+	version, err := binary.ReadUvarint(src)
+	if err != nil {
+		return 0, fmt.Errorf("entity: unable to read the snapshot header, %w", err)
+	}
+
+	return uint32(version), nil
+}
+
+// writeHeader writes the version of the schema of a snapshot
+func writeHeader(dst io.Writer, version uint32) error {
+	_, err := dst.Write(binary.AppendUvarint(bytes.Clone(magic), uint64(version)))
+	return err
+}
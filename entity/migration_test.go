@@ -0,0 +1,182 @@
+package entity
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/kelindar/column"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotVersion(t *testing.T) {
+	c := newVersioned(1, column.ForUint16())
+	id := insertVersioned(t, c, "hello", uint16(5))
+
+	var buffer bytes.Buffer
+	assert.NoError(t, c.SnapshotTo(&buffer))
+	assert.True(t, bytes.HasPrefix(buffer.Bytes(), magic))
+
+	out := newVersioned(1, column.ForUint16())
+	assert.NoError(t, out.RestoreFrom(&buffer))
+	assert.Equal(t, uint32(1), out.Version())
+	assert.NoError(t, out.Get(id, func(v Object) error {
+		assert.Equal(t, "hello", v.Message())
+		return nil
+	}))
+}
+
+func TestSnapshotLegacy(t *testing.T) {
+	c := newVersioned(1, column.ForUint16())
+	id := insertVersioned(t, c, "hello", uint16(5))
+
+	// Snapshots without a header are restored as the first version
+	var buffer bytes.Buffer
+	assert.NoError(t, c.Collection.Snapshot(&buffer))
+
+	out := newVersioned(1, column.ForUint16())
+	assert.NoError(t, out.RestoreFrom(&buffer))
+	assert.True(t, out.Exists(id))
+}
+
+func TestMigrate(t *testing.T) {
+	c := newVersioned(1, column.ForUint16())
+	id := insertVersioned(t, c, "hello", uint16(5))
+	insertVersioned(t, c, "world", uint16(7))
+
+	var buffer bytes.Buffer
+	assert.NoError(t, c.SnapshotTo(&buffer))
+
+	// Version 2 widens the column, version 3 renames the message
+	out := newVersioned(3, column.ForUint32())
+	assert.NoError(t, out.RestoreFrom(&buffer, Migration{
+		From:   1,
+		Schema: column.Object{"msg": "", "hp": uint16(0)},
+		Upgrade: func(row column.Object) error {
+			row["hp"] = uint32(row["hp"].(uint16)) * 10
+			return nil
+		},
+	}, Migration{
+		From: 2,
+		Upgrade: func(row column.Object) error {
+			row["msg"] = row["msg"].(string) + "!"
+			return nil
+		},
+	}))
+
+	assert.Equal(t, 2, out.Count())
+	assert.NoError(t, out.Query(func(txn *column.Txn) error {
+		return txn.QueryKey(id, func(r column.Row) error {
+			msg, _ := r.String("msg")
+			hp, _ := r.Uint32("hp")
+			assert.Equal(t, "hello!", msg)
+			assert.Equal(t, uint32(50), hp)
+			return nil
+		})
+	}))
+}
+
+func TestMigrateUnlisted(t *testing.T) {
+	c := newVersioned(1, column.ForUint16())
+	id := insertVersioned(t, c, "hello", uint16(5))
+
+	var buffer bytes.Buffer
+	assert.NoError(t, c.SnapshotTo(&buffer))
+
+	// Only the changed column is listed, the message is read with its current type
+	out := newVersioned(2, column.ForUint32())
+	assert.NoError(t, out.RestoreFrom(&buffer, Migration{
+		From:   1,
+		Schema: column.Object{"hp": uint16(0)},
+		Upgrade: func(row column.Object) error {
+			row["hp"] = uint32(row["hp"].(uint16))
+			return nil
+		},
+	}))
+
+	assert.NoError(t, out.Get(id, func(v Object) error {
+		assert.Equal(t, "hello", v.Message())
+		return nil
+	}))
+}
+
+func TestMigrateMissingColumn(t *testing.T) {
+	c := newVersioned(1, column.ForUint16())
+	c.Collection.CreateColumn("tag", column.ForEnum())
+	id := insertVersioned(t, c, "hello", uint16(5))
+	assert.NoError(t, c.Query(func(txn *column.Txn) error {
+		return txn.QueryKey(id, func(r column.Row) error {
+			r.SetEnum("tag", "red")
+			return nil
+		})
+	}))
+
+	var buffer bytes.Buffer
+	assert.NoError(t, c.SnapshotTo(&buffer))
+	snapshot := buffer.Bytes()
+
+	// The column can neither be carried nor dropped silently
+	assert.ErrorContains(t, newVersioned(2, column.ForUint16()).
+		RestoreFrom(bytes.NewReader(snapshot), Migration{From: 1}), `column "tag" is missing`)
+
+	// Once listed in the schema, the upgrade can drop it
+	out := newVersioned(2, column.ForUint16())
+	assert.NoError(t, out.RestoreFrom(bytes.NewReader(snapshot), Migration{
+		From:   1,
+		Schema: column.Object{"tag": ""},
+		Upgrade: func(row column.Object) error {
+			delete(row, "tag")
+			return nil
+		},
+	}))
+	assert.True(t, out.Exists(id))
+}
+
+func TestMigrateErrors(t *testing.T) {
+	c := newVersioned(2, column.ForUint16())
+	insertVersioned(t, c, "hello", uint16(5))
+
+	var buffer bytes.Buffer
+	assert.NoError(t, c.SnapshotTo(&buffer))
+	snapshot := buffer.Bytes()
+
+	// Saved with a newer version
+	assert.ErrorContains(t, newVersioned(1, column.ForUint16()).
+		RestoreFrom(bytes.NewReader(snapshot)), "newer version 2")
+
+	// Missing migration for one of the versions
+	assert.ErrorContains(t, newVersioned(4, column.ForUint16()).
+		RestoreFrom(bytes.NewReader(snapshot), Migration{From: 3}), "no migration from version 2")
+
+	// Failing upgrade
+	assert.ErrorContains(t, newVersioned(3, column.ForUint16()).
+		RestoreFrom(bytes.NewReader(snapshot), Migration{
+			From: 2,
+			Upgrade: func(row column.Object) error {
+				return assert.AnError
+			},
+		}), "unable to migrate")
+}
+
+// newVersioned creates a collection with a message and a numeric column
+func newVersioned(version uint32, hp column.Column) *Collection[Object] {
+	c := NewCollection("test", version, cursorFor)
+	c.CreateColumn("msg", column.ForString())
+	c.CreateColumn("hp", hp)
+	return c
+}
+
+// insertVersioned inserts a row into the collection and returns its identifier
+func insertVersioned(t *testing.T, c *Collection[Object], msg string, hp any) string {
+	id, err := c.Insert(func(v Object) error {
+		v.SetMessage(msg)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Query(func(txn *column.Txn) error {
+		return txn.QueryKey(id, func(r column.Row) error {
+			r.SetAny("hp", hp)
+			return nil
+		})
+	}))
+	return id
+}
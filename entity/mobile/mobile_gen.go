@@ -0,0 +1,193 @@
+// Code generated by entitygen; DO NOT EDIT.
+
+package mobile
+
+import (
+	"github.com/kelindar/column"
+	"github.com/kelindar/ecs/entity"
+	"github.com/kelindar/ecs/state"
+	"github.com/kelindar/tile"
+)
+
+// Collection represents a collection of mobile objects
+type Collection = entity.Collection[Mobile]
+
+// NewCollection creates a new mobile object collection
+func NewCollection() *Collection {
+	db := entity.NewCollection("mobiles.bin", 1, fromTxn)
+	db.CreateColumn("img", column.ForUint32())  // Image index
+	db.CreateColumn("at", column.ForUint32())   // Location as packed tile.Point
+	db.CreateColumn("move", column.ForUint16()) // Movement vector
+	db.CreateColumn("solid", column.ForBool())  // Whether it blocks the movement
+	db.CreateColumn("team", column.ForUint16()) // Team, zero when unaffiliated
+	db.CreateColumn("path", column.ForString()) // Path as packed waypoints
+	db.CreateIndex("routing", "path", func(r column.Reader) bool {
+		return r.String() != ""
+	})
+	return db
+}
+
+// Mobile represents a view on a current row
+type Mobile struct {
+	id interface {
+		Get() (string, bool)
+	}
+	img interface {
+		Get() (uint32, bool)
+		Set(value uint32)
+	}
+	at interface {
+		Get() (uint32, bool)
+		Set(value uint32)
+	}
+	move interface {
+		Get() (uint16, bool)
+		Set(value uint16)
+	}
+	solid interface {
+		Get() bool
+		Set(value bool)
+	}
+	team interface {
+		Get() (uint16, bool)
+		Set(value uint16)
+	}
+	path interface {
+		Get() (string, bool)
+		Set(value string)
+	}
+}
+
+// fromTxn creates a statically-typed mapping for a transaction
+func fromTxn(txn *column.Txn) Mobile {
+	return Mobile{
+		id:    txn.Key(),
+		img:   txn.Uint32("img"),
+		at:    txn.Uint32("at"),
+		move:  txn.Uint16("move"),
+		solid: txn.Bool("solid"),
+		team:  txn.Uint16("team"),
+		path:  txn.String("path"),
+	}
+}
+
+// ID returns the unique identifier of the mobile object
+func (e *Mobile) ID() string {
+	v, _ := e.id.Get()
+	return v
+}
+
+// ---------------------------------- Image ----------------------------------
+
+// Image reads the image index
+func (e *Mobile) Image() uint32 {
+	v, _ := e.img.Get()
+	return v
+}
+
+// SetImage writes the image index
+func (e *Mobile) SetImage(v uint32) {
+	e.img.Set(v)
+}
+
+// ---------------------------------- Location ----------------------------------
+
+// Location reads the current location
+func (e *Mobile) Location() tile.Point {
+	v, _ := e.at.Get()
+	return tile.At(int16(v>>16), int16(v))
+}
+
+// SetLocation writes the current location
+func (e *Mobile) SetLocation(v tile.Point) {
+	e.at.Set(v.Integer())
+}
+
+// ---------------------------------- Movement ----------------------------------
+
+// Movement reads the movement action
+func (e *Mobile) Movement() state.Movement {
+	v, _ := e.move.Get()
+	return state.Movement(v)
+}
+
+// SetMovement writes the movement action
+func (e *Mobile) SetMovement(v state.Movement) {
+	e.move.Set(uint16(v))
+}
+
+// ---------------------------------- Solid ----------------------------------
+
+// Solid reads the solidity flag
+func (e *Mobile) Solid() bool {
+	v := e.solid.Get()
+	return v
+}
+
+// SetSolid writes the solidity flag
+func (e *Mobile) SetSolid(v bool) {
+	e.solid.Set(v)
+}
+
+// ---------------------------------- Team ----------------------------------
+
+// Team reads the team identifier
+func (e *Mobile) Team() uint16 {
+	v, _ := e.team.Get()
+	return v
+}
+
+// SetTeam writes the team identifier
+func (e *Mobile) SetTeam(v uint16) {
+	e.team.Set(v)
+}
+
+// ---------------------------------- Path ----------------------------------
+
+// Path reads the path towards a destination
+func (e *Mobile) Path() state.Path {
+	v, _ := e.path.Get()
+	return state.Path(v)
+}
+
+// SetPath writes the path towards a destination
+func (e *Mobile) SetPath(v state.Path) {
+	e.path.Set(string(v))
+}
+
+// ---------------------------------- Record ----------------------------------
+
+// Record represents a mobile object as a plain record, keyed by its unique identifier
+// and with its components decoded, which can be encoded in JSON.
+type Record struct {
+	ID       string         `json:"id"`
+	Image    uint32         `json:"img"`
+	Location state.Point    `json:"at"`
+	Movement state.Movement `json:"move"`
+	Solid    bool           `json:"solid"`
+	Team     uint16         `json:"team"`
+	Path     state.Path     `json:"path"`
+}
+
+// Record reads all of the components into a record
+func (e *Mobile) Record() Record {
+	return Record{
+		ID:       e.ID(),
+		Image:    e.Image(),
+		Location: state.Point(e.Location()),
+		Movement: e.Movement(),
+		Solid:    e.Solid(),
+		Team:     e.Team(),
+		Path:     e.Path(),
+	}
+}
+
+// SetRecord writes all of the components from a record, except for the identifier
+func (e *Mobile) SetRecord(r Record) {
+	e.SetImage(r.Image)
+	e.SetLocation(tile.Point(r.Location))
+	e.SetMovement(r.Movement)
+	e.SetSolid(r.Solid)
+	e.SetTeam(r.Team)
+	e.SetPath(r.Path)
+}
@@ -0,0 +1,69 @@
+// Code generated by entitygen; DO NOT EDIT.
+
+package mobile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kelindar/ecs/state"
+	"github.com/kelindar/tile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratedMobile(t *testing.T) {
+	var wantImage uint32 = 42
+	var wantLocation tile.Point = tile.At(1, 2)
+	var wantMovement state.Movement = state.NewMovement(tile.East, 5, time.Second, 400*time.Millisecond)
+	var wantSolid bool = true
+	var wantTeam uint16 = 42
+	var wantPath state.Path = state.NewPath(tile.At(1, 2))
+
+	c := NewCollection()
+	id, err := c.Insert(func(v Mobile) error {
+		v.SetImage(wantImage)
+		v.SetLocation(wantLocation)
+		v.SetMovement(wantMovement)
+		v.SetSolid(wantSolid)
+		v.SetTeam(wantTeam)
+		v.SetPath(wantPath)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, c.Count())
+
+	// Read back every component
+	assert.NoError(t, c.Get(id, func(v Mobile) error {
+		assert.Equal(t, id, v.ID())
+		assert.Equal(t, wantImage, v.Image())
+		assert.Equal(t, wantLocation, v.Location())
+		assert.Equal(t, wantMovement, v.Movement())
+		assert.Equal(t, wantSolid, v.Solid())
+		assert.Equal(t, wantTeam, v.Team())
+		assert.Equal(t, wantPath, v.Path())
+		return nil
+	}))
+
+	// Copy the components through a record
+	var record Record
+	assert.NoError(t, c.Get(id, func(v Mobile) error {
+		record = v.Record()
+		return nil
+	}))
+	assert.Equal(t, id, record.ID)
+
+	clone, err := c.Insert(func(v Mobile) error {
+		v.SetRecord(record)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Get(clone, func(v Mobile) error {
+		assert.Equal(t, wantImage, v.Image())
+		assert.Equal(t, wantLocation, v.Location())
+		assert.Equal(t, wantMovement, v.Movement())
+		assert.Equal(t, wantSolid, v.Solid())
+		assert.Equal(t, wantTeam, v.Team())
+		assert.Equal(t, wantPath, v.Path())
+		return nil
+	}))
+}
@@ -14,7 +14,7 @@ func TestMobile(t *testing.T) {
 	assert.NotNil(t, c)
 
 	// Insert
-	err := c.Insert(func(mobile Mobile) error {
+	_, err := c.Insert(func(mobile Mobile) error {
 		mobile.SetLocation(tile.At(1, 1))
 		mobile.SetMovement(state.NewMovement(tile.East, 5, time.Second, 400*time.Millisecond))
 		return nil
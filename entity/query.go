@@ -0,0 +1,245 @@
+package entity
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/kelindar/column"
+)
+
+// Query represents a typed query over a collection of entities. The query is
+// built step by step and is executed within a single transaction by one of its
+// terminal operations: Range, Count or First.
+type Query[T any] struct {
+	owner  *Collection[T]
+	filter []func(*column.Txn)
+	where  []func(T) bool
+	order  *Order[T]
+	limit  int
+}
+
+// Select starts a new query over the entities of the collection
+func (c *Collection[T]) Select() *Query[T] {
+	return &Query[T]{owner: c}
+}
+
+// With filters down the entities to the ones present in all of the indexes
+func (q *Query[T]) With(indexes ...string) *Query[T] {
+	q.filter = append(q.filter, func(txn *column.Txn) {
+		txn.With(indexes...)
+	})
+	return q
+}
+
+// Without filters out the entities present in any of the indexes
+func (q *Query[T]) Without(indexes ...string) *Query[T] {
+	q.filter = append(q.filter, func(txn *column.Txn) {
+		txn.Without(indexes...)
+	})
+	return q
+}
+
+// Union extends the entities to the ones present in any of the indexes
+func (q *Query[T]) Union(indexes ...string) *Query[T] {
+	q.filter = append(q.filter, func(txn *column.Txn) {
+		txn.Union(indexes...)
+	})
+	return q
+}
+
+// WithInt filters down the entities by the value of a numerical column
+func (q *Query[T]) WithInt(name string, predicate func(v int64) bool) *Query[T] {
+	q.filter = append(q.filter, func(txn *column.Txn) {
+		txn.WithInt(name, predicate)
+	})
+	return q
+}
+
+// WithUint filters down the entities by the value of a numerical column
+func (q *Query[T]) WithUint(name string, predicate func(v uint64) bool) *Query[T] {
+	q.filter = append(q.filter, func(txn *column.Txn) {
+		txn.WithUint(name, predicate)
+	})
+	return q
+}
+
+// WithFloat filters down the entities by the value of a numerical column
+func (q *Query[T]) WithFloat(name string, predicate func(v float64) bool) *Query[T] {
+	q.filter = append(q.filter, func(txn *column.Txn) {
+		txn.WithFloat(name, predicate)
+	})
+	return q
+}
+
+// WithString filters down the entities by the value of a string column
+func (q *Query[T]) WithString(name string, predicate func(v string) bool) *Query[T] {
+	q.filter = append(q.filter, func(txn *column.Txn) {
+		txn.WithString(name, predicate)
+	})
+	return q
+}
+
+// Where filters down the entities by a predicate over the typed view. Unlike
+// column filters, this is evaluated while iterating over the entities.
+func (q *Query[T]) Where(predicate func(v T) bool) *Query[T] {
+	q.where = append(q.where, predicate)
+	return q
+}
+
+// OrderBy sorts the entities in the specified order during iteration
+func (q *Query[T]) OrderBy(order Order[T]) *Query[T] {
+	q.order = &order
+	return q
+}
+
+// Limit limits the number of entities the query returns
+func (q *Query[T]) Limit(n int) *Query[T] {
+	q.limit = n
+	return q
+}
+
+// Range iterates over all of the entities matching the query
+func (q *Query[T]) Range(fn func(v T)) error {
+	return q.owner.Collection.Query(func(txn *column.Txn) error {
+		q.execute(txn, q.limit, fn)
+		return nil
+	})
+}
+
+// Count returns the number of entities matching the query
+func (q *Query[T]) Count() (count int) {
+	q.owner.Collection.Query(func(txn *column.Txn) error {
+		if len(q.where) == 0 && q.limit <= 0 {
+			q.apply(txn)
+			count = txn.Count()
+			return nil
+		}
+
+		q.execute(txn, q.limit, func(T) { count++ })
+		return nil
+	})
+	return
+}
+
+// First invokes the callback with the first entity matching the query and
+// returns whether such entity was found
+func (q *Query[T]) First(fn func(v T)) (found bool) {
+	q.owner.Collection.Query(func(txn *column.Txn) error {
+		q.execute(txn, 1, func(v T) {
+			found = true
+			fn(v)
+		})
+		return nil
+	})
+	return
+}
+
+// apply applies the column filters on the transaction
+func (q *Query[T]) apply(txn *column.Txn) {
+	for _, filter := range q.filter {
+		filter(txn)
+	}
+}
+
+// matches returns whether the entity matches all of the predicates
+func (q *Query[T]) matches(v T) bool {
+	for _, predicate := range q.where {
+		if !predicate(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// execute executes the query on the transaction and calls the callback for each
+// matching entity, up to the limit unless it's zero
+func (q *Query[T]) execute(txn *column.Txn, limit int, fn func(v T)) {
+	q.apply(txn)
+	cursor := q.owner.read(txn)
+	if q.order != nil {
+		q.executeSorted(txn, cursor, limit, fn)
+		return
+	}
+
+	count, stop := 0, false
+	txn.Range(func(idx uint32) {
+		if stop || !q.matches(cursor) {
+			return // the remaining rows are skipped once the limit is reached
+		}
+
+		count++
+		fn(cursor)
+		stop = limit > 0 && count >= limit
+	})
+}
+
+// executeSorted collects the matching entities, sorts them and then calls the
+// callback for each one of them, in order
+func (q *Query[T]) executeSorted(txn *column.Txn, cursor T, limit int, fn func(v T)) {
+	var matches []uint32
+	txn.Range(func(idx uint32) {
+		if q.matches(cursor) {
+			matches = append(matches, idx)
+		}
+	})
+
+	matches = q.order.sort(txn, cursor, matches)
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	for _, idx := range matches {
+		txn.QueryAt(idx, func(column.Row) error {
+			fn(cursor)
+			return nil
+		})
+	}
+}
+
+// ---------------------------------- Order ----------------------------------
+
+// Order represents a sort order of the entities of a query
+type Order[T any] struct {
+	sort func(txn *column.Txn, cursor T, indices []uint32) []uint32
+}
+
+// Ascending sorts the entities by a key, in the ascending order
+func Ascending[T any, K cmp.Ordered](key func(v T) K) Order[T] {
+	return orderBy(key, cmp.Compare[K])
+}
+
+// Descending sorts the entities by a key, in the descending order
+func Descending[T any, K cmp.Ordered](key func(v T) K) Order[T] {
+	return orderBy(key, func(a, b K) int {
+		return cmp.Compare(b, a)
+	})
+}
+
+// orderBy creates a stable sort order by a key, given a comparison function
+func orderBy[T any, K cmp.Ordered](key func(v T) K, compare func(a, b K) int) Order[T] {
+	type entry struct {
+		idx uint32
+		key K
+	}
+
+	return Order[T]{
+		sort: func(txn *column.Txn, cursor T, indices []uint32) []uint32 {
+			entries := make([]entry, 0, len(indices))
+			for _, idx := range indices {
+				txn.QueryAt(idx, func(column.Row) error {
+					entries = append(entries, entry{idx: idx, key: key(cursor)})
+					return nil
+				})
+			}
+
+			slices.SortStableFunc(entries, func(a, b entry) int {
+				return compare(a.key, b.key)
+			})
+
+			for i, e := range entries {
+				indices[i] = e.idx
+			}
+			return indices
+		},
+	}
+}
@@ -0,0 +1,135 @@
+package entity
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kelindar/column"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery(t *testing.T) {
+	c := newQueryCollection(t, "delta", "alpha", "charlie", "bravo", "echo", "apple")
+
+	// With an index
+	assert.Equal(t, 2, c.Select().With("a").Count())
+
+	// Without an index
+	assert.Equal(t, 4, c.Select().Without("a").Count())
+
+	// Union of indexes
+	assert.Equal(t, 3, c.Select().With("a").Union("b").Count())
+
+	// Column predicate
+	assert.Equal(t, 4, c.Select().WithString("msg", func(v string) bool {
+		return len(v) == 5
+	}).Count())
+
+	// Typed predicate with limit
+	assert.Equal(t, 2, c.Select().Where(func(v Object) bool {
+		return strings.Contains(v.Message(), "e")
+	}).Limit(2).Count())
+
+	// Unknown index
+	assert.Equal(t, 0, c.Select().With("unknown").Count())
+}
+
+func TestQueryOrder(t *testing.T) {
+	c := newQueryCollection(t, "delta", "alpha", "charlie", "bravo", "echo", "apple")
+	message := func(v Object) string {
+		return v.Message()
+	}
+
+	var sorted []string
+	assert.NoError(t, c.Select().OrderBy(Ascending(message)).Range(func(v Object) {
+		sorted = append(sorted, v.Message())
+	}))
+	assert.Equal(t, []string{"alpha", "apple", "bravo", "charlie", "delta", "echo"}, sorted)
+
+	sorted = sorted[:0]
+	assert.NoError(t, c.Select().
+		Without("a").
+		OrderBy(Descending(message)).
+		Limit(3).
+		Range(func(v Object) {
+			sorted = append(sorted, v.Message())
+		}))
+	assert.Equal(t, []string{"echo", "delta", "charlie"}, sorted)
+}
+
+func TestQueryLimit(t *testing.T) {
+	messages := make([]string, 20000)
+	for i := range messages {
+		messages[i] = strconv.Itoa(i)
+	}
+
+	// The limit spans several blocks of rows, the iteration stops right after it
+	c := newQueryCollection(t, messages...)
+	var visited []string
+	assert.NoError(t, c.Select().Limit(100).Range(func(v Object) {
+		visited = append(visited, v.Message())
+	}))
+	assert.Equal(t, messages[:100], visited)
+
+	// A query can be executed again once the iteration was stopped
+	assert.Equal(t, 20000, c.Select().Where(func(Object) bool { return true }).Count())
+	assert.Equal(t, 1, c.Select().Limit(1).Count())
+}
+
+func TestQueryFirst(t *testing.T) {
+	c := newQueryCollection(t, "delta", "alpha", "charlie")
+
+	var first string
+	query := c.Select().OrderBy(Descending(func(v Object) int {
+		return len(v.Message())
+	}))
+	assert.True(t, query.First(func(v Object) {
+		first = v.Message()
+	}))
+	assert.Equal(t, "charlie", first)
+	assert.Equal(t, 3, query.Count())
+
+	assert.False(t, c.Select().With("b").First(func(v Object) {
+		assert.Fail(t, "unexpected entity")
+	}))
+}
+
+func TestQueryConcurrent(t *testing.T) {
+	c := newQueryCollection(t, "alpha", "bravo", "charlie")
+	query := c.Select().Limit(2)
+
+	// The same query can be executed concurrently, the limit being left untouched
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.True(t, query.First(func(Object) {}))
+			assert.Equal(t, 2, query.Count())
+		}()
+	}
+
+	wg.Wait()
+}
+
+// newQueryCollection creates a collection with messages, indexed by their initial
+func newQueryCollection(t *testing.T, messages ...string) *Collection[Object] {
+	c := NewCollection("test", 1, cursorFor)
+	c.CreateColumn("msg", column.ForString())
+	for _, initial := range []string{"a", "b"} {
+		c.CreateIndex(initial, "msg", func(r column.Reader) bool {
+			return strings.HasPrefix(r.String(), initial)
+		})
+	}
+
+	for _, msg := range messages {
+		_, err := c.Insert(func(v Object) error {
+			v.SetMessage(msg)
+			return nil
+		})
+		assert.NoError(t, err)
+	}
+	return c
+}
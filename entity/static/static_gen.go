@@ -0,0 +1,146 @@
+// Code generated by entitygen; DO NOT EDIT.
+
+package static
+
+import (
+	"github.com/kelindar/column"
+	"github.com/kelindar/ecs/entity"
+	"github.com/kelindar/ecs/state"
+	"github.com/kelindar/tile"
+)
+
+// Collection represents a collection of static objects
+type Collection = entity.Collection[Static]
+
+// NewCollection creates a new static object collection
+func NewCollection() *Collection {
+	db := entity.NewCollection("statics.bin", 1, fromTxn)
+	db.CreateColumn("img", column.ForUint32())  // Image index
+	db.CreateColumn("at", column.ForUint32())   // Location as packed tile.Point
+	db.CreateColumn("solid", column.ForBool())  // Whether it blocks the movement
+	db.CreateColumn("opaque", column.ForBool()) // Whether it blocks the line of sight
+	return db
+}
+
+// Static represents a view on a current row
+type Static struct {
+	id interface {
+		Get() (string, bool)
+	}
+	img interface {
+		Get() (uint32, bool)
+		Set(value uint32)
+	}
+	at interface {
+		Get() (uint32, bool)
+		Set(value uint32)
+	}
+	solid interface {
+		Get() bool
+		Set(value bool)
+	}
+	opaque interface {
+		Get() bool
+		Set(value bool)
+	}
+}
+
+// fromTxn creates a statically-typed mapping for a transaction
+func fromTxn(txn *column.Txn) Static {
+	return Static{
+		id:     txn.Key(),
+		img:    txn.Uint32("img"),
+		at:     txn.Uint32("at"),
+		solid:  txn.Bool("solid"),
+		opaque: txn.Bool("opaque"),
+	}
+}
+
+// ID returns the unique identifier of the static object
+func (e *Static) ID() string {
+	v, _ := e.id.Get()
+	return v
+}
+
+// ---------------------------------- Image ----------------------------------
+
+// Image reads the image index
+func (e *Static) Image() uint32 {
+	v, _ := e.img.Get()
+	return v
+}
+
+// SetImage writes the image index
+func (e *Static) SetImage(v uint32) {
+	e.img.Set(v)
+}
+
+// ---------------------------------- Location ----------------------------------
+
+// Location reads the current location
+func (e *Static) Location() tile.Point {
+	v, _ := e.at.Get()
+	return tile.At(int16(v>>16), int16(v))
+}
+
+// SetLocation writes the current location
+func (e *Static) SetLocation(v tile.Point) {
+	e.at.Set(v.Integer())
+}
+
+// ---------------------------------- Solid ----------------------------------
+
+// Solid reads the solidity flag
+func (e *Static) Solid() bool {
+	v := e.solid.Get()
+	return v
+}
+
+// SetSolid writes the solidity flag
+func (e *Static) SetSolid(v bool) {
+	e.solid.Set(v)
+}
+
+// ---------------------------------- Opaque ----------------------------------
+
+// Opaque reads the opacity flag
+func (e *Static) Opaque() bool {
+	v := e.opaque.Get()
+	return v
+}
+
+// SetOpaque writes the opacity flag
+func (e *Static) SetOpaque(v bool) {
+	e.opaque.Set(v)
+}
+
+// ---------------------------------- Record ----------------------------------
+
+// Record represents a static object as a plain record, keyed by its unique identifier
+// and with its components decoded, which can be encoded in JSON.
+type Record struct {
+	ID       string      `json:"id"`
+	Image    uint32      `json:"img"`
+	Location state.Point `json:"at"`
+	Solid    bool        `json:"solid"`
+	Opaque   bool        `json:"opaque"`
+}
+
+// Record reads all of the components into a record
+func (e *Static) Record() Record {
+	return Record{
+		ID:       e.ID(),
+		Image:    e.Image(),
+		Location: state.Point(e.Location()),
+		Solid:    e.Solid(),
+		Opaque:   e.Opaque(),
+	}
+}
+
+// SetRecord writes all of the components from a record, except for the identifier
+func (e *Static) SetRecord(r Record) {
+	e.SetImage(r.Image)
+	e.SetLocation(tile.Point(r.Location))
+	e.SetSolid(r.Solid)
+	e.SetOpaque(r.Opaque)
+}
@@ -0,0 +1,59 @@
+// Code generated by entitygen; DO NOT EDIT.
+
+package static
+
+import (
+	"testing"
+
+	"github.com/kelindar/tile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratedStatic(t *testing.T) {
+	var wantImage uint32 = 42
+	var wantLocation tile.Point = tile.At(1, 2)
+	var wantSolid bool = true
+	var wantOpaque bool = true
+
+	c := NewCollection()
+	id, err := c.Insert(func(v Static) error {
+		v.SetImage(wantImage)
+		v.SetLocation(wantLocation)
+		v.SetSolid(wantSolid)
+		v.SetOpaque(wantOpaque)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, c.Count())
+
+	// Read back every component
+	assert.NoError(t, c.Get(id, func(v Static) error {
+		assert.Equal(t, id, v.ID())
+		assert.Equal(t, wantImage, v.Image())
+		assert.Equal(t, wantLocation, v.Location())
+		assert.Equal(t, wantSolid, v.Solid())
+		assert.Equal(t, wantOpaque, v.Opaque())
+		return nil
+	}))
+
+	// Copy the components through a record
+	var record Record
+	assert.NoError(t, c.Get(id, func(v Static) error {
+		record = v.Record()
+		return nil
+	}))
+	assert.Equal(t, id, record.ID)
+
+	clone, err := c.Insert(func(v Static) error {
+		v.SetRecord(record)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, c.Get(clone, func(v Static) error {
+		assert.Equal(t, wantImage, v.Image())
+		assert.Equal(t, wantLocation, v.Location())
+		assert.Equal(t, wantSolid, v.Solid())
+		assert.Equal(t, wantOpaque, v.Opaque())
+		return nil
+	}))
+}
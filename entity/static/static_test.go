@@ -12,7 +12,7 @@ func TestStatic(t *testing.T) {
 	assert.NotNil(t, c)
 
 	// Insert
-	err := c.Insert(func(v Static) error {
+	_, err := c.Insert(func(v Static) error {
 		v.SetLocation(tile.At(1, 1))
 		return nil
 	})
@@ -7,6 +7,7 @@ toolchain go1.23.4
 require (
 	github.com/kelindar/column v0.1.0
 	github.com/kelindar/tile v1.6.1
+	github.com/klauspost/compress v1.15.6
 	github.com/rs/xid v1.4.0
 	github.com/stretchr/testify v1.9.0
 	go.uber.org/multierr v1.8.0
@@ -19,7 +20,6 @@ require (
 	github.com/kelindar/iostream v1.4.0 // indirect
 	github.com/kelindar/simd v1.1.2 // indirect
 	github.com/kelindar/smutex v1.0.0 // indirect
-	github.com/klauspost/compress v1.15.6 // indirect
 	github.com/klauspost/cpuid/v2 v2.0.13 // indirect
 	github.com/kr/pretty v0.3.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
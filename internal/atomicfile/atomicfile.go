@@ -0,0 +1,81 @@
+// Package atomicfile writes files atomically, so that a crash in the middle of
+// a write never leaves a partially written file behind.
+package atomicfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Suffix is appended to the name of a file while it is staged
+const Suffix = ".tmp"
+
+// WriteFile atomically replaces the file with the content written by the function
+func WriteFile(filename string, write func(io.Writer) error) error {
+	if err := Stage(filename, write); err != nil {
+		return err
+	}
+	return Commit(filename)
+}
+
+// Stage writes the content into a temporary file next to the specified one and
+// syncs it to the disk. The file itself is only replaced once committed.
+func Stage(filename string, write func(io.Writer) error) error {
+	if err := os.MkdirAll(filepath.Dir(filename), os.ModePerm); err != nil {
+		return err
+	}
+
+	file, err := os.Create(filename + Suffix)
+	if err != nil {
+		return err
+	}
+
+	// Write and sync the content, discarding the temporary file on failure
+	if err := write(file); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	return file.Close()
+}
+
+// Commit atomically replaces the file with its staged temporary file and syncs
+// the directory, so that the rename itself is durable.
+func Commit(filename string) error {
+	if err := os.Rename(filename+Suffix, filename); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(filename))
+}
+
+// Discard removes the staged temporary file of the specified file, if any
+func Discard(filename string) error {
+	if err := os.Remove(filename + Suffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// IsStaged returns whether the specified file has a staged temporary file
+func IsStaged(filename string) bool {
+	_, err := os.Stat(filename + Suffix)
+	return err == nil
+}
+
+// syncDir syncs the directory entries to the disk
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+	return file.Sync()
+}
@@ -0,0 +1,61 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "dir", "file.bin")
+	assert.NoError(t, WriteFile(filename, writeString("hello")))
+	assert.NoError(t, WriteFile(filename, writeString("world")))
+	assert.False(t, IsStaged(filename))
+
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+	assert.Equal(t, "world", string(data))
+}
+
+func TestStage(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file.bin")
+	assert.NoError(t, WriteFile(filename, writeString("old")))
+
+	// The file is kept intact until the staged one is committed
+	assert.NoError(t, Stage(filename, writeString("new")))
+	assert.True(t, IsStaged(filename))
+	data, _ := os.ReadFile(filename)
+	assert.Equal(t, "old", string(data))
+
+	assert.NoError(t, Commit(filename))
+	data, _ = os.ReadFile(filename)
+	assert.Equal(t, "new", string(data))
+
+	// Discarding the staged file keeps the file intact
+	assert.NoError(t, Stage(filename, writeString("discarded")))
+	assert.NoError(t, Discard(filename))
+	assert.NoError(t, Discard(filename))
+	assert.False(t, IsStaged(filename))
+	data, _ = os.ReadFile(filename)
+	assert.Equal(t, "new", string(data))
+}
+
+func TestStageError(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "file.bin")
+	assert.Error(t, Stage(filename, func(io.Writer) error {
+		return errors.New("boom")
+	}))
+	assert.False(t, IsStaged(filename))
+	assert.Error(t, Commit(filename))
+}
+
+func writeString(v string) func(io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := io.WriteString(w, v)
+		return err
+	}
+}
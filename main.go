@@ -7,14 +7,18 @@ import (
 	"syscall"
 
 	"github.com/kelindar/ecs/system/movement"
+	"github.com/kelindar/ecs/system/pathfinding"
 	"github.com/kelindar/ecs/system/snapshot"
+	"github.com/kelindar/ecs/system/visibility"
 	"github.com/kelindar/ecs/world"
 )
 
 func main() {
 	world, err := world.Open("save",
 		new(movement.System),
+		new(pathfinding.System),
 		new(snapshot.System),
+		new(visibility.System),
 	)
 	if err != nil {
 		panic(err)
@@ -1,6 +1,7 @@
 package state
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -59,26 +60,114 @@ func (v Movement) Duration() time.Duration {
 }
 
 // Update updates the movement vector based on the elapsed time and returns
-// and updated vetor and whether the distance has changed or not.
+// and updated vetor and whether the distance has changed or not. The time left
+// to move the current tile is carried over until it runs out, at which point
+// the next tile starts with the full velocity.
 func (v Movement) Update(dt time.Duration) (Movement, bool) {
 	dist := v.Distance()
-	left := v.Duration() - dt
+	if dist == 0 {
+		return v, false
+	}
 
-	// Once the timer reaches zero, decrement a distance by one
-	if left <= 0 {
-		dist--
+	// Until the timer reaches zero, the mobile is still moving the current tile
+	left := v.Duration() - dt
+	if left > 0 {
+		return NewMovement(v.Direction(), dist, v.Velocity(), left), false
 	}
 
-	// If there's more tiles to move, update the time with the
-	// velocity
-	if dist > 0 {
+	// Once the timer reaches zero, decrement a distance by one and if there's
+	// more tiles to move, restart the time with the velocity
+	if dist--; dist > 0 {
 		left = v.Velocity()
 	}
 
-	return NewMovement(v.Direction(), dist, v.Velocity(), left), dist != v.Distance()
+	return NewMovement(v.Direction(), dist, v.Velocity(), max(left, 0)), true
+}
+
+// Slow returns an updated vector with the time left to move one tile multiplied
+// by a factor, up to 3 seconds.
+func (v Movement) Slow(factor int) Movement {
+	left := min(v.Duration()*time.Duration(max(factor, 1)), moveMaxTime)
+	return NewMovement(v.Direction(), v.Distance(), v.Velocity(), left)
 }
 
 // String returns string representation of a movement vector, for debugging
 func (v Movement) String() string {
 	return fmt.Sprintf("movement %d%s, %s/tile, 𝚫t=%s", v.Distance(), v.Direction(), v.Velocity(), v.Duration())
 }
+
+// movementRecord represents the decoded fields of a movement vector, in JSON
+type movementRecord struct {
+	Direction string `json:"direction"`
+	Distance  int    `json:"distance"`
+	Velocity  string `json:"velocity"`
+	Duration  string `json:"duration"`
+}
+
+// directions contains the names of the directions, in JSON
+var directions = [...]string{"north", "northeast", "east", "southeast", "south", "southwest", "west", "northwest"}
+
+// MarshalJSON encodes the movement vector with its decoded fields, or null if
+// there is no movement.
+func (v Movement) MarshalJSON() ([]byte, error) {
+	if v == 0 {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(movementRecord{
+		Direction: directions[v.Direction()],
+		Distance:  v.Distance(),
+		Velocity:  v.Velocity().String(),
+		Duration:  v.Duration().String(),
+	})
+}
+
+// UnmarshalJSON decodes the movement vector from its decoded fields
+func (v *Movement) UnmarshalJSON(data []byte) error {
+	var record *movementRecord
+	if err := json.Unmarshal(data, &record); err != nil || record == nil {
+		*v = 0
+		return err
+	}
+
+	direction := -1
+	for i, name := range directions {
+		if name == record.Direction {
+			direction = i
+		}
+	}
+
+	if direction < 0 {
+		return fmt.Errorf("movement: unknown direction %q", record.Direction)
+	}
+
+	velocity, err := parseDuration(record.Velocity)
+	if err != nil {
+		return err
+	}
+
+	duration, err := parseDuration(record.Duration)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case record.Distance < 0 || record.Distance > 7:
+		return fmt.Errorf("movement: distance of %d tiles is out of range", record.Distance)
+	case velocity < 0 || velocity > moveMaxTime:
+		return fmt.Errorf("movement: velocity of %s/tile is out of range", velocity)
+	case duration < 0 || duration > moveMaxTime:
+		return fmt.Errorf("movement: duration of %s is out of range", duration)
+	}
+
+	*v = NewMovement(tile.Direction(direction), record.Distance, velocity, duration)
+	return nil
+}
+
+// parseDuration parses a duration, where an empty one is zero
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
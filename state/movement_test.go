@@ -1,6 +1,7 @@
 package state
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -23,6 +24,31 @@ func TestMovementUpdate(t *testing.T) {
 	assert.True(t, moved)
 	assert.Equal(t, 4, updated.Distance())
 	assert.Equal(t, time.Second, updated.Duration())
+
+	// The time left is carried over while it's longer than the elapsed time
+	for i := 0; i < 9; i++ {
+		updated, moved = updated.Update(100 * time.Millisecond)
+		assert.False(t, moved)
+	}
+	assert.Equal(t, 100*time.Millisecond, updated.Duration())
+
+	updated, moved = updated.Update(100 * time.Millisecond)
+	assert.True(t, moved)
+	assert.Equal(t, 3, updated.Distance())
+	assert.Equal(t, time.Second, updated.Duration())
+
+	// Without any distance left, there's nothing to update
+	updated, moved = NewMovement(tile.East, 0, time.Second, 0).Update(time.Second)
+	assert.False(t, moved)
+	assert.Equal(t, 0, updated.Distance())
+}
+
+func TestMovementSlow(t *testing.T) {
+	v := NewMovement(tile.East, 5, time.Second, 400*time.Millisecond)
+	assert.Equal(t, 800*time.Millisecond, v.Slow(2).Duration())
+	assert.Equal(t, 400*time.Millisecond, v.Slow(0).Duration())
+	assert.Equal(t, 3*time.Second, v.Slow(10).Duration())
+	assert.Equal(t, 5, v.Slow(2).Distance())
 }
 
 func TestMovementPanic(t *testing.T) {
@@ -34,3 +60,28 @@ func TestMovementPanic(t *testing.T) {
 		NewMovement(tile.East, 5, time.Hour, time.Hour)
 	})
 }
+
+func TestMovementJSON(t *testing.T) {
+	v := NewMovement(tile.East, 5, time.Second, 400*time.Millisecond)
+	data, err := json.Marshal(v)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"direction":"east","distance":5,"velocity":"1s","duration":"400ms"}`, string(data))
+
+	var out Movement
+	assert.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, v, out)
+
+	// No movement is null
+	data, err = json.Marshal(Movement(0))
+	assert.NoError(t, err)
+	assert.Equal(t, "null", string(data))
+	assert.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, Movement(0), out)
+
+	// Invalid movements
+	assert.Error(t, json.Unmarshal([]byte(`{"direction":"up","distance":1}`), &out))
+	assert.Error(t, json.Unmarshal([]byte(`{"direction":"east","distance":9}`), &out))
+	assert.Error(t, json.Unmarshal([]byte(`{"direction":"east","velocity":"10s"}`), &out))
+	assert.Error(t, json.Unmarshal([]byte(`{"direction":"east","velocity":"-1s"}`), &out))
+	assert.Error(t, json.Unmarshal([]byte(`{"direction":"east","duration":"-1s"}`), &out))
+}
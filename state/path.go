@@ -0,0 +1,134 @@
+package state
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kelindar/tile"
+)
+
+// ---------------------------------- Path ----------------------------------
+
+// Path represents a route towards a destination, packed into a string so that it
+// can be stored in a column. The first point is the destination, followed by the
+// waypoints left to reach it. A path with no waypoints is yet to be planned, and
+// an empty path has no destination.
+type Path string
+
+// NewPath creates a new path towards a destination, which is yet to be planned
+func NewPath(destination tile.Point) Path {
+	return Path(appendPoint(nil, destination))
+}
+
+// Destination returns the destination of the path, if any
+func (p Path) Destination() (tile.Point, bool) {
+	if len(p) < 4 {
+		return tile.Point{}, false
+	}
+	return pointAt(p, 0), true
+}
+
+// Len returns the number of waypoints left to reach the destination
+func (p Path) Len() int {
+	return max(len(p)/4-1, 0)
+}
+
+// Waypoint returns the waypoint at the specified position
+func (p Path) Waypoint(i int) tile.Point {
+	return pointAt(p, i+1)
+}
+
+// Waypoints returns all of the waypoints left to reach the destination
+func (p Path) Waypoints() []tile.Point {
+	points := make([]tile.Point, 0, p.Len())
+	for i := 0; i < p.Len(); i++ {
+		points = append(points, p.Waypoint(i))
+	}
+	return points
+}
+
+// WithWaypoints returns the path with its waypoints replaced
+func (p Path) WithWaypoints(waypoints []tile.Point) Path {
+	destination, ok := p.Destination()
+	if !ok {
+		return p
+	}
+
+	buffer := appendPoint(make([]byte, 0, 4*(len(waypoints)+1)), destination)
+	for _, point := range waypoints {
+		buffer = appendPoint(buffer, point)
+	}
+	return Path(buffer)
+}
+
+// Skip returns the path without its first n waypoints
+func (p Path) Skip(n int) Path {
+	if len(p) < 4 {
+		return p
+	}
+
+	n = min(n, p.Len())
+	return p[:4] + p[4*(n+1):]
+}
+
+// String returns string representation of a path, for debugging
+func (p Path) String() string {
+	destination, ok := p.Destination()
+	if !ok {
+		return "path none"
+	}
+	return fmt.Sprintf("path to %s, %d waypoints", destination, p.Len())
+}
+
+// pathRecord represents the destination and the waypoints of a path, in JSON
+type pathRecord struct {
+	Destination Point   `json:"destination"`
+	Waypoints   []Point `json:"waypoints"`
+}
+
+// MarshalJSON encodes the destination and the waypoints of the path, or null if
+// there is no destination.
+func (p Path) MarshalJSON() ([]byte, error) {
+	destination, ok := p.Destination()
+	if !ok {
+		return []byte("null"), nil
+	}
+
+	record := pathRecord{
+		Destination: Point(destination),
+		Waypoints:   make([]Point, 0, p.Len()),
+	}
+	for _, point := range p.Waypoints() {
+		record.Waypoints = append(record.Waypoints, Point(point))
+	}
+	return json.Marshal(record)
+}
+
+// UnmarshalJSON decodes the path from its destination and waypoints
+func (p *Path) UnmarshalJSON(data []byte) error {
+	var record *pathRecord
+	if err := json.Unmarshal(data, &record); err != nil || record == nil {
+		*p = ""
+		return err
+	}
+
+	waypoints := make([]tile.Point, 0, len(record.Waypoints))
+	for _, point := range record.Waypoints {
+		waypoints = append(waypoints, tile.Point(point))
+	}
+
+	*p = NewPath(tile.Point(record.Destination)).WithWaypoints(waypoints)
+	return nil
+}
+
+// appendPoint appends a packed point to the buffer
+func appendPoint(buffer []byte, point tile.Point) []byte {
+	return binary.BigEndian.AppendUint32(buffer, point.Integer())
+}
+
+// pointAt decodes the packed point at the specified position
+func pointAt(p Path, i int) tile.Point {
+	v := binary.BigEndian.Uint32([]byte(p[4*i : 4*i+4]))
+	return tile.At(int16(v>>16), int16(v))
+}
@@ -0,0 +1,61 @@
+package state
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kelindar/tile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPath(t *testing.T) {
+	p := NewPath(tile.At(3, -2))
+	dest, ok := p.Destination()
+	assert.True(t, ok)
+	assert.Equal(t, tile.At(3, -2), dest)
+	assert.Equal(t, 0, p.Len())
+	assert.Equal(t, "path to 3,-2, 0 waypoints", p.String())
+
+	// Plan the path
+	p = p.WithWaypoints([]tile.Point{tile.At(1, 0), tile.At(2, 0), tile.At(3, -1), tile.At(3, -2)})
+	assert.Equal(t, 4, p.Len())
+	assert.Equal(t, tile.At(2, 0), p.Waypoint(1))
+
+	// Skip the waypoints
+	p = p.Skip(2)
+	assert.Equal(t, []tile.Point{tile.At(3, -1), tile.At(3, -2)}, p.Waypoints())
+	assert.Equal(t, 0, p.Skip(10).Len())
+
+	dest, ok = p.Skip(10).Destination()
+	assert.True(t, ok)
+	assert.Equal(t, tile.At(3, -2), dest)
+}
+
+func TestPathEmpty(t *testing.T) {
+	var p Path
+	_, ok := p.Destination()
+	assert.False(t, ok)
+	assert.Equal(t, 0, p.Len())
+	assert.Empty(t, p.Waypoints())
+	assert.Equal(t, Path(""), p.WithWaypoints([]tile.Point{tile.At(1, 1)}))
+	assert.Equal(t, Path(""), p.Skip(1))
+	assert.Equal(t, "path none", p.String())
+}
+
+func TestPathJSON(t *testing.T) {
+	p := NewPath(tile.At(3, -2)).WithWaypoints([]tile.Point{tile.At(1, 0), tile.At(2, -1)})
+	data, err := json.Marshal(p)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"destination":{"x":3,"y":-2},"waypoints":[{"x":1,"y":0},{"x":2,"y":-1}]}`, string(data))
+
+	var out Path
+	assert.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, p, out)
+
+	// No destination is null
+	data, err = json.Marshal(Path(""))
+	assert.NoError(t, err)
+	assert.Equal(t, "null", string(data))
+	assert.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, Path(""), out)
+}
@@ -0,0 +1,10 @@
+package state
+
+// ---------------------------------- Point ----------------------------------
+
+// Point represents a tile.Point which is encoded in JSON with its "x" and "y"
+// coordinates. Both types convert directly into one another.
+type Point struct {
+	X int16 `json:"x"` // X coordinate
+	Y int16 `json:"y"` // Y coordinate
+}
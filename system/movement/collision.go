@@ -0,0 +1,112 @@
+package movement
+
+import (
+	"github.com/kelindar/column"
+	"github.com/kelindar/ecs/entity/mobile"
+	"github.com/kelindar/ecs/entity/static"
+	"github.com/kelindar/ecs/world"
+	"github.com/kelindar/tile"
+)
+
+// Resolution represents the way a solid mobile resolves a collision with another
+// solid entity standing in its way.
+type Resolution uint8
+
+// Various collision resolutions
+const (
+	Stop  Resolution = iota // The mobile stops in front of the obstacle
+	Slide                   // The mobile slides to an adjacent free tile, or stops
+	Swap                    // The mobile swaps places with an allied mobile, or stops
+)
+
+// occupancy tracks the solid entities on the map during an update, since the
+// moves done during the update are only reflected in the spatial index once the
+// update is committed.
+type occupancy struct {
+	occupants func(tile.Point) []world.Entity // Committed entities per tile
+	solid     map[world.Entity]uint16         // Team of the solid entities
+	moved     map[uint32]tile.Point           // Pending locations of the moved mobiles
+	arrived   map[tile.Point][]uint32         // Mobiles moved onto a tile
+	swaps     []swap                          // Pending swaps with allied mobiles
+}
+
+// swap represents a pending move of a mobile swapped with another one
+type swap struct {
+	index uint32     // The index of the swapped mobile
+	to    tile.Point // The location of the swapped mobile
+}
+
+// newOccupancy creates a new occupancy tracker
+func newOccupancy(occupants func(tile.Point) []world.Entity) *occupancy {
+	return &occupancy{
+		occupants: occupants,
+		solid:     make(map[world.Entity]uint16),
+		moved:     make(map[uint32]tile.Point),
+		arrived:   make(map[tile.Point][]uint32),
+	}
+}
+
+// reset loads the solid entities of the collections and clears the pending moves
+func (o *occupancy) reset(mobiles *mobile.Collection, statics *static.Collection) error {
+	clear(o.solid)
+	clear(o.moved)
+	clear(o.arrived)
+	o.swaps = o.swaps[:0]
+
+	if err := mobiles.Query(func(txn *column.Txn) error {
+		team := txn.Uint16("team")
+		return txn.With("solid").Range(func(idx uint32) {
+			v, _ := team.Get()
+			o.solid[world.Entity{Kind: world.KindMobile, Index: idx}] = v
+		})
+	}); err != nil {
+		return err
+	}
+
+	return statics.Query(func(txn *column.Txn) error {
+		return txn.With("solid").Range(func(idx uint32) {
+			o.solid[world.Entity{Kind: world.KindStatic, Index: idx}] = 0
+		})
+	})
+}
+
+// blocker returns the solid entity standing at the location, other than the mobile
+// itself. Solid statics block every mobile, while solid mobiles only block the
+// mobiles that are solid themselves.
+func (o *occupancy) blocker(self uint32, solid bool, at tile.Point) (world.Entity, bool) {
+	for _, entity := range o.occupants(at) {
+		if entity.Kind == world.KindMobile {
+			if moved, ok := o.moved[entity.Index]; !solid || entity.Index == self || ok && moved != at {
+				continue // Not colliding, the mobile itself, or it has left the tile
+			}
+		}
+
+		if _, ok := o.solid[entity]; ok {
+			return entity, true
+		}
+	}
+
+	if !solid {
+		return world.Entity{}, false
+	}
+
+	for _, idx := range o.arrived[at] {
+		entity := world.Entity{Kind: world.KindMobile, Index: idx}
+		if _, ok := o.solid[entity]; ok && idx != self && o.moved[idx] == at {
+			return entity, true
+		}
+	}
+	return world.Entity{}, false
+}
+
+// isAlly returns whether the entity is a mobile of the same non-zero team
+func (o *occupancy) isAlly(entity world.Entity, team uint16) bool {
+	other, ok := o.solid[entity]
+	return ok && entity.Kind == world.KindMobile && team != 0 && other == team
+}
+
+// move records a pending move of the mobile
+func (o *occupancy) move(idx uint32, to tile.Point) {
+	o.moved[idx] = to
+	o.arrived[to] = append(o.arrived[to], idx)
+}
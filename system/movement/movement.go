@@ -5,6 +5,7 @@ import (
 
 	"github.com/kelindar/column"
 	"github.com/kelindar/ecs/entity/mobile"
+	"github.com/kelindar/ecs/entity/static"
 	"github.com/kelindar/ecs/state"
 	"github.com/kelindar/ecs/world"
 	"github.com/kelindar/tile"
@@ -12,11 +13,17 @@ import (
 
 // Assert contract compliance
 var _ world.System[any] = new(System)
+var _ world.Accessor = new(System)
 
 // System represents a system that handles all movement of mobile objects
 type System struct {
-	grid    *tile.Grid[any]
-	mobiles *mobile.Collection
+	Mode      world.Traversal // The way the mobiles traverse the map, walking by default
+	Collision Resolution      // The way the collisions are resolved, stopping by default
+	grid      *tile.Grid[any]
+	terrains  *world.Terrains
+	mobiles   *mobile.Collection
+	statics   *static.Collection
+	occupancy *occupancy
 }
 
 // Interval specifies how often the system should run
@@ -24,10 +31,21 @@ func (s *System) Interval() time.Duration {
 	return 100 * time.Millisecond
 }
 
+// Access specifies the components accessed by the system
+func (s *System) Access() world.Access {
+	return world.Access{
+		Reads:  []string{"grid", "terrains", "mobiles.solid", "mobiles.team", "statics.at", "statics.solid"},
+		Writes: []string{"mobiles.at", "mobiles.move", "spatial"},
+	}
+}
+
 // Attach attaches the system to the world context
 func (s *System) Attach(w *world.World[any]) error {
 	s.grid = w.Grid
+	s.terrains = w.Terrains
 	s.mobiles = w.Mobiles
+	s.statics = w.Statics
+	s.occupancy = newOccupancy(w.EntitiesAt)
 	s.mobiles.CreateIndex("moving", "move", func(r column.Reader) bool {
 		return state.Movement(r.Uint()).Distance() > 0
 	})
@@ -37,17 +55,46 @@ func (s *System) Attach(w *world.World[any]) error {
 // Update is called periodically to update the system
 func (s *System) Update(dt *world.Clock) error {
 	elapsed := dt.Elapsed
-	return s.mobiles.Range(func(m mobile.Mobile) {
-		if !s.tryUpdate(m, elapsed) {
+	if err := s.occupancy.reset(s.mobiles, s.statics); err != nil {
+		return err
+	}
+
+	if err := s.mobiles.RangeIndexed(func(idx uint32, m mobile.Mobile) {
+		if !s.tryUpdate(idx, m, elapsed) {
 			return // No movement
 		}
-	}, "moving")
+	}, "moving"); err != nil {
+		return err
+	}
+
+	// Move the allies which were swapped, once the moves are committed
+	for _, swap := range s.occupancy.swaps {
+		if err := s.mobiles.UpdateAt(swap.index, func(m mobile.Mobile) error {
+			m.SetLocation(swap.to)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mode returns the way the mobiles traverse the map
+func (s *System) mode() world.Traversal {
+	if s.Mode == 0 {
+		return world.Walkable
+	}
+	return s.Mode
 }
 
 // tryUpdate attempts to update a movement state and location of the mobile
-func (s *System) tryUpdate(m mobile.Mobile, dt time.Duration) (moved bool) {
+func (s *System) tryUpdate(idx uint32, m mobile.Mobile, dt time.Duration) (moved bool) {
+	if _, swapped := s.occupancy.moved[idx]; swapped {
+		return false // already moved by an ally during this update
+	}
+
 	movement := m.Movement()
-	location := m.Location()
+	from := m.Location()
 
 	// Update the movement vector and store it
 	movement, moved = movement.Update(dt)
@@ -56,16 +103,59 @@ func (s *System) tryUpdate(m mobile.Mobile, dt time.Duration) (moved bool) {
 		return false // not moved
 	}
 
-	// Try to move and check whether the location is within bounds
-	location = location.Move(movement.Direction())
-	_, ok := s.grid.At(location.X, location.Y)
+	// Try to move and check whether the terrain can be traversed, e.g. not a wall
+	location := from.Move(movement.Direction())
+	terrain, ok := s.enter(location)
 	if !ok {
-		return false // out of bounds
+		return false // out of bounds or blocked by terrain
 	}
 
-	// TODO: check tile for terrain type
+	// Resolve the collision with a solid entity standing in the way
+	if blocker, blocked := s.occupancy.blocker(idx, m.Solid(), location); blocked {
+		switch {
+		case s.Collision == Slide:
+			if location, terrain, ok = s.slide(idx, m.Solid(), from, movement.Direction()); !ok {
+				return false // no free tile to slide to
+			}
+		case s.Collision == Swap && s.occupancy.isAlly(blocker, m.Team()):
+			s.occupancy.swaps = append(s.occupancy.swaps, swap{index: blocker.Index, to: from})
+			s.occupancy.move(blocker.Index, from)
+		default:
+			return false // blocked by a solid entity
+		}
+	}
+
+	// Moving through a costly terrain, such as mud, takes longer
+	if movement.Distance() > 0 && terrain.Cost > 1 {
+		m.SetMovement(movement.Slow(int(terrain.Cost)))
+	}
 
 	// Update the current location
+	s.occupancy.move(idx, location)
 	m.SetLocation(location)
 	return true
 }
+
+// enter returns the terrain at the location and whether it can be traversed
+func (s *System) enter(at tile.Point) (world.Terrain, bool) {
+	target, ok := s.grid.At(at.X, at.Y)
+	if !ok {
+		return world.Terrain{}, false
+	}
+
+	terrain := s.terrains.Of(target.Value())
+	return terrain, terrain.Allows(s.mode())
+}
+
+// slide finds a free tile adjacent to the blocked direction, on either side of it
+func (s *System) slide(idx uint32, solid bool, from tile.Point, direction tile.Direction) (tile.Point, world.Terrain, bool) {
+	for _, side := range []tile.Direction{(direction + 7) % 8, (direction + 1) % 8} {
+		location := from.Move(side)
+		if terrain, ok := s.enter(location); ok {
+			if _, blocked := s.occupancy.blocker(idx, solid, location); !blocked {
+				return location, terrain, true
+			}
+		}
+	}
+	return tile.Point{}, world.Terrain{}, false
+}
@@ -5,32 +5,101 @@ import (
 	"time"
 
 	"github.com/kelindar/ecs/entity/mobile"
+	"github.com/kelindar/ecs/entity/static"
 	"github.com/kelindar/ecs/state"
 	"github.com/kelindar/ecs/world"
 	"github.com/kelindar/tile"
 	"github.com/stretchr/testify/assert"
 )
 
+func TestAccess(t *testing.T) {
+	access := new(System).Access()
+	assert.Contains(t, access.Reads, "terrains")
+	assert.Contains(t, access.Writes, "spatial")
+}
+
 func TestTryUpdate(t *testing.T) {
 	s, _ := newSystem()
 
 	// Move west, should be okay
 	assert.NoError(t, s.mobiles.UpdateAt(0, func(v mobile.Mobile) error {
-		assert.True(t, s.tryUpdate(v, time.Second))
+		assert.True(t, s.tryUpdate(0, v, time.Second))
 		return nil
 	}))
 
 	// Move west again, should fail given that we reached the bounds of the map
 	assert.NoError(t, s.mobiles.UpdateAt(0, func(v mobile.Mobile) error {
-		assert.False(t, s.tryUpdate(v, time.Second))
+		assert.False(t, s.tryUpdate(0, v, time.Second))
+		return nil
+	}))
+}
+
+func TestTerrain(t *testing.T) {
+	s, w := newSystem()
+
+	// The wall blocks the way to the west
+	w.SetTerrain(tile.At(0, 0), world.TerrainWall)
+	assert.NoError(t, s.mobiles.UpdateAt(0, func(v mobile.Mobile) error {
+		assert.False(t, s.tryUpdate(0, v, time.Second))
+		assert.Equal(t, tile.At(1, 0), v.Location())
+		return nil
+	}))
+
+	// The mud slows down the movement, but can be walked through
+	w.SetTerrain(tile.At(0, 0), world.TerrainMud)
+	assert.NoError(t, s.mobiles.UpdateAt(0, func(v mobile.Mobile) error {
+		assert.True(t, s.tryUpdate(0, v, time.Second))
+		return nil
+	}))
+	assert.NoError(t, s.mobiles.UpdateAt(0, func(v mobile.Mobile) error {
+		assert.Equal(t, tile.At(0, 0), v.Location())
+		assert.Equal(t, 2*time.Second, v.Movement().Duration())
+		return nil
+	}))
+}
+
+func TestTerrainCost(t *testing.T) {
+	for cost, ticks := range map[uint16]int{1: 5, 2: 9, 5: 21} {
+		w := world.Create[any](9, 9, new(System))
+		w.Terrains.Define(8, world.Terrain{Name: "test", Flags: world.Walkable, Cost: cost})
+		for x := int16(1); x <= 5; x++ {
+			w.SetTerrain(tile.At(x, 0), 8)
+		}
+
+		// Every tile entered delays the next step by its cost
+		insertMobile(w, tile.At(0, 0), state.NewMovement(tile.East, 5, 100*time.Millisecond, 100*time.Millisecond), false, 0)
+		elapsed := 0
+		for ; elapsed < 100 && len(w.EntitiesAt(tile.At(5, 0))) == 0; elapsed++ {
+			assert.NoError(t, w.Step(100*time.Millisecond))
+		}
+		assert.Equal(t, ticks, elapsed, "cost %d", cost)
+	}
+}
+
+func TestStep(t *testing.T) {
+	_, w := newSystem()
+
+	// First tick moves the mobile west by one tile
+	assert.NoError(t, w.Step(time.Second))
+	assert.NoError(t, w.Mobiles.UpdateAt(0, func(v mobile.Mobile) error {
+		assert.Equal(t, tile.At(0, 0), v.Location())
+		assert.Equal(t, 4, v.Movement().Distance())
+		return nil
+	}))
+
+	// The following ticks are blocked by the bounds of the map
+	assert.NoError(t, w.StepN(10, time.Second))
+	assert.NoError(t, w.Mobiles.UpdateAt(0, func(v mobile.Mobile) error {
+		assert.Equal(t, tile.At(0, 0), v.Location())
+		assert.Equal(t, 0, v.Movement().Distance())
 		return nil
 	}))
 }
 
 // newSystem creates a new system for testing purposes
-func newSystem() (*System, *world.World) {
+func newSystem() (*System, *world.World[any]) {
 	system := new(System)
-	world := world.Create(9, 9, system)
+	world := world.Create[any](9, 9, system)
 	world.Mobiles.Insert(func(v mobile.Mobile) error {
 		v.SetMovement(state.NewMovement(tile.West, 5, time.Second, 400*time.Millisecond))
 		v.SetLocation(tile.At(1, 0))
@@ -38,3 +107,57 @@ func newSystem() (*System, *world.World) {
 	})
 	return system, world
 }
+
+func TestCollision(t *testing.T) {
+	tests := []struct {
+		name      string
+		collision Resolution
+		solid     bool
+		team      uint16
+		expect    tile.Point
+		other     tile.Point
+	}{
+		{name: "stop", collision: Stop, solid: true, expect: tile.At(2, 2), other: tile.At(1, 2)},
+		{name: "ghost", collision: Stop, solid: false, expect: tile.At(1, 2), other: tile.At(1, 2)},
+		{name: "slide", collision: Slide, solid: true, expect: tile.At(1, 3), other: tile.At(1, 2)},
+		{name: "swap", collision: Swap, solid: true, team: 1, expect: tile.At(1, 2), other: tile.At(2, 2)},
+		{name: "swap-enemy", collision: Swap, solid: true, team: 2, expect: tile.At(2, 2), other: tile.At(1, 2)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			system := &System{Collision: tc.collision}
+			w := world.Create[any](9, 9, system)
+			insertMobile(w, tile.At(2, 2), state.NewMovement(tile.West, 1, time.Second, 100*time.Millisecond), tc.solid, 1)
+			insertMobile(w, tile.At(1, 2), 0, true, tc.team)
+
+			assert.NoError(t, w.Step(time.Second))
+			assert.Contains(t, w.EntitiesAt(tc.expect), world.Entity{Kind: world.KindMobile, Index: 0})
+			assert.Contains(t, w.EntitiesAt(tc.other), world.Entity{Kind: world.KindMobile, Index: 1})
+		})
+	}
+}
+
+func TestCollisionStatic(t *testing.T) {
+	_, w := newSystem()
+	w.Statics.Insert(func(v static.Static) error {
+		v.SetLocation(tile.At(0, 0))
+		v.SetSolid(true)
+		return nil
+	})
+
+	// The building blocks even the mobiles which are not solid
+	assert.NoError(t, w.Step(time.Second))
+	assert.Equal(t, []world.Entity{{Kind: world.KindMobile, Index: 0}}, w.EntitiesAt(tile.At(1, 0)))
+}
+
+// insertMobile inserts a mobile for testing purposes
+func insertMobile(w *world.World[any], at tile.Point, move state.Movement, solid bool, team uint16) {
+	w.Mobiles.Insert(func(v mobile.Mobile) error {
+		v.SetLocation(at)
+		v.SetMovement(move)
+		v.SetSolid(solid)
+		v.SetTeam(team)
+		return nil
+	})
+}
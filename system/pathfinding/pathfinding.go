@@ -0,0 +1,163 @@
+package pathfinding
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kelindar/ecs/entity/mobile"
+	"github.com/kelindar/ecs/state"
+	"github.com/kelindar/ecs/world"
+	"github.com/kelindar/tile"
+)
+
+// Assert contract compliance
+var _ world.System[any] = new(System)
+var _ world.Accessor = new(System)
+var _ world.Dependent = new(System)
+
+// System represents a system that guides the mobiles along their path towards
+// a destination. It plans the path once a destination is set with SetPath, then
+// emits a movement for each straight leg of the path, planning again whenever the
+// mobile is pushed off its path.
+type System struct {
+	Mode     world.Traversal // The way the mobiles traverse the map, walking by default
+	Velocity time.Duration   // The time to move one tile, 500ms by default
+	grid     *tile.Grid[any]
+	costOf   func(tile.Value) uint16
+	mobiles  *mobile.Collection
+}
+
+// Interval specifies how often the system should run
+func (s *System) Interval() time.Duration {
+	return 100 * time.Millisecond
+}
+
+// Access specifies the components accessed by the system
+func (s *System) Access() world.Access {
+	return world.Access{
+		Reads:  []string{"grid", "terrains", "mobiles.at"},
+		Writes: []string{"mobiles.path", "mobiles.move"},
+	}
+}
+
+// After specifies the systems to run before this one
+func (s *System) After() []string {
+	return nil
+}
+
+// Before specifies that the movements are emitted before the mobiles are moved
+func (s *System) Before() []string {
+	return []string{"movement"}
+}
+
+// Attach attaches the system to the world context
+func (s *System) Attach(w *world.World[any]) error {
+	if s.Velocity < 0 || s.Velocity > 3*time.Second {
+		return fmt.Errorf("pathfinding: velocity of %s per tile is out of the [0,3s] range", s.Velocity)
+	}
+
+	mode := s.Mode
+	if mode == 0 {
+		mode = world.Walkable
+	}
+
+	s.grid = w.Grid
+	s.costOf = w.Terrains.Cost(mode)
+	s.mobiles = w.Mobiles
+	return nil
+}
+
+// Update is called periodically to update the system
+func (s *System) Update(dt *world.Clock) error {
+	return s.mobiles.Range(func(m mobile.Mobile) {
+		s.tryUpdate(m)
+	}, "routing")
+}
+
+// tryUpdate attempts to emit the next leg of the path of the mobile and returns
+// whether a movement was emitted. The waypoints are only dropped from the path
+// once the mobile has reached them.
+func (s *System) tryUpdate(m mobile.Mobile) bool {
+	path := m.Path()
+	location := m.Location()
+	if reached := reachedOf(location, path); reached > 0 {
+		path = path.Skip(reached)
+		m.SetPath(path)
+	}
+
+	destination, _ := path.Destination()
+	if location == destination {
+		m.SetPath("")
+		return false // arrived
+	}
+
+	if m.Movement().Distance() > 0 {
+		return false // still moving along the current leg
+	}
+
+	// Plan the path if it was never planned or the mobile was pushed off of it
+	if path.Len() == 0 || !isAdjacent(location, path.Waypoint(0)) {
+		waypoints, _, found := s.grid.Path(location, destination, s.costOf)
+		if !found {
+			m.SetPath("")
+			return false // unreachable
+		}
+
+		path = path.WithWaypoints(waypoints[1:])
+		m.SetPath(path)
+	}
+
+	// Emit the movement along the straight leg
+	direction, distance := legOf(location, path)
+	m.SetMovement(state.NewMovement(direction, distance, s.velocity(), s.velocity()))
+	return true
+}
+
+// velocity returns the time to move one tile
+func (s *System) velocity() time.Duration {
+	if s.Velocity == 0 {
+		return 500 * time.Millisecond
+	}
+	return s.Velocity
+}
+
+// legOf returns the direction and the distance of the first straight leg of the
+// path, up to the maximum distance of a movement.
+func legOf(from tile.Point, path state.Path) (tile.Direction, int) {
+	direction, _ := directionOf(from, path.Waypoint(0))
+	distance := 1
+	for distance < 7 && distance < path.Len() {
+		if next, ok := directionOf(path.Waypoint(distance-1), path.Waypoint(distance)); !ok || next != direction {
+			break
+		}
+		distance++
+	}
+	return direction, distance
+}
+
+// reachedOf returns the number of waypoints of the current leg which the mobile
+// has reached, up to and including its location.
+func reachedOf(location tile.Point, path state.Path) int {
+	for i := 0; i < min(path.Len(), 7); i++ {
+		if path.Waypoint(i) == location {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// isAdjacent returns whether the two points are adjacent to one another
+func isAdjacent(from, to tile.Point) bool {
+	_, ok := directionOf(from, to)
+	return ok
+}
+
+// directionOf returns the direction from one point to an adjacent one
+func directionOf(from, to tile.Point) (tile.Direction, bool) {
+	for direction := tile.North; direction <= tile.NorthWest; direction++ {
+		if from.Move(direction) == to {
+			return direction, true
+		}
+	}
+	return 0, false
+}
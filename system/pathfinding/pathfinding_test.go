@@ -0,0 +1,111 @@
+package pathfinding
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kelindar/ecs/entity/mobile"
+	"github.com/kelindar/ecs/state"
+	"github.com/kelindar/ecs/system/movement"
+	"github.com/kelindar/ecs/world"
+	"github.com/kelindar/tile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccess(t *testing.T) {
+	access := new(System).Access()
+	assert.Contains(t, access.Reads, "terrains")
+}
+
+func TestPathfinding(t *testing.T) {
+	w := newWorld(state.NewPath(tile.At(4, 0)))
+
+	// A wall stands in the way, so the mobile has to go around it
+	for y := int16(0); y < 4; y++ {
+		w.SetTerrain(tile.At(2, y), world.TerrainWall)
+	}
+
+	// The waypoint is only dropped once the mobile is seen to have reached it
+	assert.NoError(t, w.Step(100*time.Millisecond))
+	assert.NoError(t, w.Mobiles.UpdateAt(0, func(v mobile.Mobile) error {
+		assert.Equal(t, tile.At(1, 0), v.Location())
+		assert.Equal(t, 12, v.Path().Len())
+		assert.Equal(t, tile.At(1, 0), v.Path().Waypoint(0))
+		return nil
+	}))
+
+	assert.NoError(t, w.StepN(100, 100*time.Millisecond))
+	assert.NoError(t, w.Mobiles.UpdateAt(0, func(v mobile.Mobile) error {
+		assert.Equal(t, tile.At(4, 0), v.Location())
+		assert.Equal(t, state.Path(""), v.Path())
+		return nil
+	}))
+}
+
+func TestDefaultVelocity(t *testing.T) {
+	w := world.Create[any](9, 9, new(movement.System), new(System))
+	w.Mobiles.Insert(func(v mobile.Mobile) error {
+		v.SetPath(state.NewPath(tile.At(4, 0)))
+		return nil
+	})
+
+	// Every tile takes 500ms, so the legs span several updates
+	assert.NoError(t, w.StepN(10, 100*time.Millisecond))
+	assert.NoError(t, w.Mobiles.UpdateAt(0, func(v mobile.Mobile) error {
+		assert.Equal(t, tile.At(2, 0), v.Location())
+		assert.Equal(t, []tile.Point{tile.At(2, 0), tile.At(3, 0), tile.At(4, 0)}, v.Path().Waypoints())
+		return nil
+	}))
+
+	assert.NoError(t, w.StepN(20, 100*time.Millisecond))
+	assert.NoError(t, w.Mobiles.UpdateAt(0, func(v mobile.Mobile) error {
+		assert.Equal(t, tile.At(4, 0), v.Location())
+		assert.Equal(t, state.Path(""), v.Path())
+		return nil
+	}))
+}
+
+func TestUnreachable(t *testing.T) {
+	w := newWorld(state.NewPath(tile.At(4, 0)))
+	w.SetTerrain(tile.At(4, 0), world.TerrainWall)
+
+	assert.NoError(t, w.Step(100*time.Millisecond))
+	assert.NoError(t, w.Mobiles.UpdateAt(0, func(v mobile.Mobile) error {
+		assert.Equal(t, tile.At(0, 0), v.Location())
+		assert.Equal(t, 0, v.Movement().Distance())
+		assert.Equal(t, state.Path(""), v.Path())
+		return nil
+	}))
+}
+
+func TestInvalidVelocity(t *testing.T) {
+	w := world.Create[any](9, 9)
+	assert.Error(t, w.Attach(&System{Velocity: 5 * time.Second}))
+	assert.Error(t, w.Attach(&System{Velocity: -time.Second}))
+	assert.NoError(t, w.Attach(&System{Velocity: 3 * time.Second}))
+}
+
+func TestLegOf(t *testing.T) {
+	path := state.NewPath(tile.At(3, 1)).WithWaypoints([]tile.Point{
+		tile.At(1, 0), tile.At(2, 0), tile.At(3, 0), tile.At(3, 1),
+	})
+
+	direction, distance := legOf(tile.At(0, 0), path)
+	assert.Equal(t, tile.East, direction)
+	assert.Equal(t, 3, distance)
+
+	direction, distance = legOf(tile.At(3, 0), path.Skip(3))
+	assert.Equal(t, tile.South, direction)
+	assert.Equal(t, 1, distance)
+}
+
+// newWorld creates a new world with a mobile following the path
+func newWorld(path state.Path) *world.World[any] {
+	w := world.Create[any](9, 9, new(movement.System), &System{Velocity: 100 * time.Millisecond})
+	w.Mobiles.Insert(func(v mobile.Mobile) error {
+		v.SetLocation(tile.At(0, 0))
+		v.SetPath(path)
+		return nil
+	})
+	return w
+}
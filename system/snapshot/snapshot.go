@@ -8,20 +8,39 @@ import (
 
 // Assert contract compliance
 var _ world.System[any] = new(System)
+var _ world.Phased = new(System)
+var _ world.Accessor = new(System)
 
 // System represents a system that handles all movement of mobile objects
 type System struct {
-	save func() error
+	save     func() error
+	interval time.Duration
 }
 
 // Interval specifies how often the system should run
 func (s *System) Interval() time.Duration {
-	return 60 * time.Second
+	if s.interval == 0 {
+		return 60 * time.Second
+	}
+	return s.interval
+}
+
+// Phase specifies that the snapshot is taken once the tick is fully processed
+func (s *System) Phase() world.Phase {
+	return world.PhasePersist
+}
+
+// Access specifies that the snapshot reads every collection of the world
+func (s *System) Access() world.Access {
+	return world.Access{
+		Reads: []string{"grid", "mobiles", "statics", "items"},
+	}
 }
 
 // Attach attaches the system to the world context
 func (s *System) Attach(w *world.World[any]) error {
 	s.save = w.Save
+	s.interval = w.Options().Autosave
 	return nil
 }
 
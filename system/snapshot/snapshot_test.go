@@ -1,11 +1,19 @@
 package snapshot
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/kelindar/ecs/world"
 	"github.com/stretchr/testify/assert"
 )
 
+func TestAccess(t *testing.T) {
+	access := new(System).Access()
+	assert.Contains(t, access.Reads, "grid")
+}
+
 func TestSnapshot(t *testing.T) {
 	var count int
 	system := new(System)
@@ -19,3 +27,20 @@ func TestSnapshot(t *testing.T) {
 	assert.NoError(t, system.Close())
 	assert.Equal(t, 2, count)
 }
+
+func TestAutosave(t *testing.T) {
+	w, err := world.OpenWith[any](t.TempDir(), world.Options{
+		Width:    9,
+		Height:   9,
+		Autosave: 100 * time.Millisecond,
+		Timestep: 50 * time.Millisecond,
+	}, new(System))
+	assert.NoError(t, err)
+
+	// The autosave interval is only known once the system is attached
+	go w.Simulate(context.Background())
+	assert.Eventually(t, func() bool {
+		return w.Stats()[0].Updates >= 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, w.Close())
+}
@@ -0,0 +1,113 @@
+package visibility
+
+import (
+	"github.com/kelindar/tile"
+)
+
+// octants contains the transformations from the first octant to all eight of them
+var octants = [8][4]int{
+	{1, 0, 0, 1}, {0, 1, 1, 0}, {0, -1, 1, 0}, {-1, 0, 0, 1},
+	{-1, 0, 0, -1}, {0, -1, -1, 0}, {0, 1, -1, 0}, {1, 0, 0, -1},
+}
+
+// shadowcast visits every tile visible from the origin within the radius, using
+// recursive shadowcasting. A tile may be visited more than once.
+func shadowcast(origin tile.Point, radius int, opaque func(tile.Point) bool, visit func(tile.Point)) {
+	visit(origin)
+	for _, octant := range octants {
+		castLight(origin, radius, 1, 1.0, 0.0, octant, opaque, visit)
+	}
+}
+
+// castLight scans the rows of an octant between the start and end slopes, and
+// recursively scans the parts of the following rows which are not in a shadow.
+func castLight(origin tile.Point, radius, row int, start, end float64, octant [4]int, opaque func(tile.Point) bool, visit func(tile.Point)) {
+	if start < end {
+		return
+	}
+
+	for j := row; j <= radius; j++ {
+		blocked, next := false, 0.0
+		for dx, dy := -j, -j; dx <= 0; dx++ {
+			left := (float64(dx) - 0.5) / (float64(dy) + 0.5)
+			right := (float64(dx) + 0.5) / (float64(dy) - 0.5)
+			if start < right {
+				continue
+			}
+
+			if end > left {
+				break
+			}
+
+			// Translate the relative coordinates into the octant
+			at := tile.At(
+				origin.X+int16(dx*octant[0]+dy*octant[1]),
+				origin.Y+int16(dx*octant[2]+dy*octant[3]),
+			)
+
+			if dx*dx+dy*dy <= radius*radius {
+				visit(at)
+			}
+
+			switch {
+			case blocked && opaque(at):
+				next = right
+			case blocked:
+				blocked = false
+				start = next
+			case opaque(at) && j < radius:
+				blocked = true
+				castLight(origin, radius, j+1, start, left, octant, opaque, visit)
+				next = right
+			}
+		}
+
+		if blocked {
+			return
+		}
+	}
+}
+
+// lineOfSight returns whether none of the tiles on the line between the two
+// points, excluding the points themselves, is opaque.
+func lineOfSight(from, to tile.Point, opaque func(tile.Point) bool) bool {
+	x0, y0, x1, y1 := int(from.X), int(from.Y), int(to.X), int(to.Y)
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := sign(x1-x0), sign(y1-y0)
+
+	// Walk the line using the Bresenham's algorithm
+	for err := dx + dy; x0 != x1 || y0 != y1; {
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+
+		if (x0 != x1 || y0 != y1) && opaque(tile.At(int16(x0), int16(y0))) {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	switch {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	default:
+		return 0
+	}
+}
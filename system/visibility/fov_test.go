@@ -0,0 +1,40 @@
+package visibility
+
+import (
+	"testing"
+
+	"github.com/kelindar/tile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShadowcast(t *testing.T) {
+	wall := map[tile.Point]bool{tile.At(5, 4): true, tile.At(5, 5): true, tile.At(5, 6): true}
+	opaque := func(p tile.Point) bool { return wall[p] }
+
+	visible := make(map[tile.Point]bool)
+	shadowcast(tile.At(3, 5), 5, opaque, func(p tile.Point) {
+		visible[p] = true
+	})
+
+	assert.True(t, visible[tile.At(3, 5)])
+	assert.True(t, visible[tile.At(4, 5)])
+	assert.True(t, visible[tile.At(5, 5)], "the wall itself is visible")
+	assert.False(t, visible[tile.At(6, 5)], "hidden behind the wall")
+	assert.False(t, visible[tile.At(7, 5)], "hidden behind the wall")
+	assert.True(t, visible[tile.At(3, 0)])
+	assert.False(t, visible[tile.At(3, -1)], "beyond the radius")
+	assert.False(t, visible[tile.At(7, 1)], "beyond the radius")
+}
+
+func TestLineOfSight(t *testing.T) {
+	wall := map[tile.Point]bool{tile.At(2, 2): true}
+	opaque := func(p tile.Point) bool { return wall[p] }
+
+	assert.False(t, lineOfSight(tile.At(0, 0), tile.At(4, 4), opaque))
+	assert.False(t, lineOfSight(tile.At(2, 0), tile.At(2, 5), opaque))
+	assert.True(t, lineOfSight(tile.At(0, 0), tile.At(2, 2), opaque))
+	assert.True(t, lineOfSight(tile.At(0, 0), tile.At(4, 0), opaque))
+	assert.True(t, lineOfSight(tile.At(0, 1), tile.At(5, 1), opaque))
+	assert.True(t, lineOfSight(tile.At(0, 0), tile.At(4, 2), opaque))
+	assert.True(t, lineOfSight(tile.At(1, 1), tile.At(1, 1), opaque))
+}
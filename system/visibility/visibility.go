@@ -0,0 +1,220 @@
+package visibility
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kelindar/column"
+	"github.com/kelindar/column/commit"
+	"github.com/kelindar/ecs/entity/mobile"
+	"github.com/kelindar/ecs/entity/static"
+	"github.com/kelindar/ecs/world"
+	"github.com/kelindar/tile"
+)
+
+// Assert contract compliance
+var _ world.System[any] = new(System)
+var _ world.Phased = new(System)
+var _ world.Accessor = new(System)
+
+// System represents a system that computes the field of view of every mobile. The
+// sight is blocked by the opaque terrain, such as walls, and by the opaque statics.
+// The field of view of a mobile is cached until either the mobile or the occluders
+// move.
+type System struct {
+	Radius     int // The sight radius, in tiles, 8 by default
+	grid       *tile.Grid[any]
+	terrains   *world.Terrains
+	mobiles    *mobile.Collection
+	statics    *static.Collection
+	changed    atomic.Bool             // Whether the statics changed since loaded
+	lock       sync.RWMutex            // Lock to guard the occluders and the sights
+	occluders  map[tile.Point]struct{} // Tiles occluded by the opaque statics
+	terrain    uint64                  // The version of the terrain of the occluders
+	generation uint64                  // The generation of the occluders
+	sights     map[uint32]*sight       // Cached field of view per mobile
+	updates    uint64                  // The number of updates of the system
+}
+
+// sight represents a cached field of view of a mobile
+type sight struct {
+	at         tile.Point              // The location of the mobile
+	generation uint64                  // The generation of the occluders
+	update     uint64                  // The last update which has seen the mobile
+	visible    map[tile.Point]struct{} // The visible tiles
+}
+
+// Interval specifies how often the system should run
+func (s *System) Interval() time.Duration {
+	return 100 * time.Millisecond
+}
+
+// Phase specifies that the field of view is computed once the mobiles have moved
+func (s *System) Phase() world.Phase {
+	return world.PhasePostUpdate
+}
+
+// Access specifies the components accessed by the system
+func (s *System) Access() world.Access {
+	return world.Access{
+		Reads: []string{"grid", "terrains", "mobiles.at", "statics.at", "statics.opaque"},
+	}
+}
+
+// Attach attaches the system to the world context
+func (s *System) Attach(w *world.World[any]) error {
+	s.grid = w.Grid
+	s.terrains = w.Terrains
+	s.mobiles = w.Mobiles
+	s.statics = w.Statics
+	s.occluders = make(map[tile.Point]struct{})
+	s.sights = make(map[uint32]*sight)
+	s.changed.Store(true)
+	s.statics.OnCommit(func(change commit.Commit) {
+		if occludes(change) {
+			s.changed.Store(true)
+		}
+	})
+	return nil
+}
+
+// occludes returns whether the commit may change the occluders, by inserting or
+// deleting statics or by changing either their location or their opacity.
+func occludes(change commit.Commit) bool {
+	for _, u := range change.Updates {
+		switch u.Column {
+		case "row", "at", "opaque":
+			if !u.IsEmpty() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Update is called periodically to update the system
+func (s *System) Update(dt *world.Clock) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.refresh(); err != nil {
+		return err
+	}
+
+	// Compute the field of view of the mobiles which have moved
+	s.updates++
+	if err := s.mobiles.RangeIndexed(func(idx uint32, m mobile.Mobile) {
+		at := m.Location()
+		if cached, ok := s.sights[idx]; ok && cached.at == at && cached.generation == s.generation {
+			cached.update = s.updates
+			return
+		}
+
+		visible := make(map[tile.Point]struct{})
+		shadowcast(at, s.radius(), s.isOpaque, func(p tile.Point) {
+			if _, ok := s.grid.At(p.X, p.Y); ok {
+				visible[p] = struct{}{}
+			}
+		})
+
+		s.sights[idx] = &sight{at: at, generation: s.generation, update: s.updates, visible: visible}
+	}); err != nil {
+		return err
+	}
+
+	// Forget the mobiles which were deleted
+	for idx, cached := range s.sights {
+		if cached.update != s.updates {
+			delete(s.sights, idx)
+		}
+	}
+	return nil
+}
+
+// refresh reloads the occluders if either the terrain or the statics have changed
+func (s *System) refresh() error {
+	if version := s.terrains.Version(); version != s.terrain {
+		s.terrain = version
+		s.generation++
+	}
+
+	if !s.changed.Swap(false) {
+		return nil
+	}
+
+	s.generation++
+	clear(s.occluders)
+	return s.statics.Query(func(txn *column.Txn) error {
+		at := txn.Uint32("at")
+		return txn.With("opaque").Range(func(idx uint32) {
+			if v, ok := at.Get(); ok {
+				s.occluders[tile.At(int16(v>>16), int16(v))] = struct{}{}
+			}
+		})
+	})
+}
+
+// radius returns the sight radius
+func (s *System) radius() int {
+	if s.Radius == 0 {
+		return 8
+	}
+	return s.Radius
+}
+
+// isOpaque returns whether the tile blocks the sight, tiles outside of the map
+// being opaque.
+func (s *System) isOpaque(at tile.Point) bool {
+	t, ok := s.grid.At(at.X, at.Y)
+	if !ok || s.terrains.Of(t.Value()).Opaque {
+		return true
+	}
+
+	_, occluded := s.occluders[at]
+	return occluded
+}
+
+// ---------------------------------- Queries ----------------------------------
+
+// LineOfSight returns whether the tile at the destination can be seen from the
+// origin, regardless of the distance between them.
+func (s *System) LineOfSight(from, to tile.Point) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return lineOfSight(from, to, s.isOpaque)
+}
+
+// CanSee returns whether the mobile at the specified index sees the tile, as of
+// the last update of the system.
+func (s *System) CanSee(idx uint32, at tile.Point) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if cached, ok := s.sights[idx]; ok {
+		_, visible := cached.visible[at]
+		return visible
+	}
+	return false
+}
+
+// FieldOfView returns the tiles seen by the mobile at the specified index, as of
+// the last update of the system, ordered by row.
+func (s *System) FieldOfView(idx uint32) []tile.Point {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	cached, ok := s.sights[idx]
+	if !ok {
+		return nil
+	}
+
+	visible := make([]tile.Point, 0, len(cached.visible))
+	for at := range cached.visible {
+		visible = append(visible, at)
+	}
+
+	slices.SortFunc(visible, func(a, b tile.Point) int {
+		return cmp.Or(cmp.Compare(a.Y, b.Y), cmp.Compare(a.X, b.X))
+	})
+	return visible
+}
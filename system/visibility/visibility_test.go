@@ -0,0 +1,107 @@
+package visibility
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kelindar/ecs/entity/mobile"
+	"github.com/kelindar/ecs/entity/static"
+	"github.com/kelindar/ecs/world"
+	"github.com/kelindar/tile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccess(t *testing.T) {
+	access := new(System).Access()
+	assert.Contains(t, access.Reads, "terrains")
+}
+
+func TestVisibility(t *testing.T) {
+	s, w := newSystem()
+	w.Statics.Insert(func(v static.Static) error {
+		v.SetLocation(tile.At(3, 1))
+		v.SetOpaque(true)
+		return nil
+	})
+
+	// The opaque static hides the tiles behind it
+	assert.NoError(t, w.Step(time.Second))
+	assert.True(t, s.CanSee(0, tile.At(3, 1)))
+	assert.False(t, s.CanSee(0, tile.At(4, 1)))
+	assert.False(t, s.LineOfSight(tile.At(1, 1), tile.At(5, 1)))
+	assert.True(t, s.LineOfSight(tile.At(1, 1), tile.At(1, 8)))
+	assert.Contains(t, s.FieldOfView(0), tile.At(0, 0))
+	assert.NotContains(t, s.FieldOfView(0), tile.At(-1, 0))
+
+	// Once the static is moved away, the field of view is computed again
+	assert.NoError(t, w.Statics.UpdateAt(0, func(v static.Static) error {
+		v.SetLocation(tile.At(1, 3))
+		return nil
+	}))
+	assert.NoError(t, w.Step(time.Second))
+	assert.True(t, s.CanSee(0, tile.At(4, 1)))
+	assert.False(t, s.CanSee(0, tile.At(1, 4)))
+
+	// The walls hide the tiles as well
+	w.SetTerrain(tile.At(2, 1), world.TerrainWall)
+	assert.NoError(t, w.Step(time.Second))
+	assert.False(t, s.CanSee(0, tile.At(4, 1)))
+
+	// The deleted mobiles are forgotten
+	assert.True(t, w.Mobiles.DeleteAt(0))
+	assert.NoError(t, w.Step(time.Second))
+	assert.False(t, s.CanSee(0, tile.At(1, 1)))
+	assert.Nil(t, s.FieldOfView(0))
+}
+
+func TestVisibilityCache(t *testing.T) {
+	s, w := newSystem()
+	assert.NoError(t, w.Step(time.Second))
+	cached := s.sights[0]
+
+	// The field of view is kept while nothing moves
+	assert.NoError(t, w.Step(time.Second))
+	assert.Same(t, cached, s.sights[0])
+
+	// The field of view is computed again once the mobile moves
+	assert.NoError(t, w.Mobiles.UpdateAt(0, func(v mobile.Mobile) error {
+		v.SetLocation(tile.At(2, 2))
+		return nil
+	}))
+	assert.NoError(t, w.Step(time.Second))
+	assert.NotSame(t, cached, s.sights[0])
+	assert.Equal(t, tile.At(2, 2), s.sights[0].at)
+
+	// Changes to the statics which cannot occlude keep the field of view
+	w.Statics.Insert(func(v static.Static) error {
+		v.SetLocation(tile.At(5, 5))
+		return nil
+	})
+	assert.NoError(t, w.Step(time.Second))
+	cached = s.sights[0]
+	assert.NoError(t, w.Statics.UpdateAt(0, func(v static.Static) error {
+		v.SetImage(7)
+		return nil
+	}))
+	assert.NoError(t, w.Step(time.Second))
+	assert.Same(t, cached, s.sights[0])
+
+	// While the ones which can occlude invalidate it
+	assert.NoError(t, w.Statics.UpdateAt(0, func(v static.Static) error {
+		v.SetOpaque(true)
+		return nil
+	}))
+	assert.NoError(t, w.Step(time.Second))
+	assert.NotSame(t, cached, s.sights[0])
+}
+
+// newSystem creates a new system for testing purposes
+func newSystem() (*System, *world.World[any]) {
+	system := new(System)
+	world := world.Create[any](9, 9, system)
+	world.Mobiles.Insert(func(v mobile.Mobile) error {
+		v.SetLocation(tile.At(1, 1))
+		return nil
+	})
+	return system, world
+}
@@ -0,0 +1,47 @@
+package world
+
+import (
+	"math"
+	"time"
+)
+
+// Pause freezes the simulation. While paused, the systems are not updated and
+// the time does not elapse for them, but the world can still be saved and
+// queried. Manual stepping with Step remains possible.
+func (w *World[T]) Pause() {
+	if !w.paused.Swap(true) {
+		w.logger.Info("simulation paused")
+	}
+}
+
+// Resume resumes a previously paused simulation.
+func (w *World[T]) Resume() {
+	if w.paused.Swap(false) {
+		w.logger.Info("simulation resumed")
+	}
+}
+
+// IsPaused returns whether the simulation is currently paused.
+func (w *World[T]) IsPaused() bool {
+	return w.paused.Load()
+}
+
+// SetTimeScale sets the rate at which the time elapses for all of the systems
+// during the simulation, 1.0 being the real time, 2.0 being twice as fast.
+func (w *World[T]) SetTimeScale(scale float64) {
+	if scale < 0 || math.IsNaN(scale) || math.IsInf(scale, 0) {
+		panic("world: time scale must be a finite, non-negative number")
+	}
+
+	w.scale.Store(math.Float64bits(scale))
+}
+
+// TimeScale returns the rate at which the time elapses during the simulation.
+func (w *World[T]) TimeScale() float64 {
+	return math.Float64frombits(w.scale.Load())
+}
+
+// scaled scales the duration by the current time scale
+func (w *World[T]) scaled(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * w.TimeScale())
+}
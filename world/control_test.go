@@ -0,0 +1,50 @@
+package world
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPauseResume(t *testing.T) {
+	var order []string
+	w := Create[any](9, 9, &fakeSystem{name: "a", every: time.Second, order: &order})
+	w.SetTimestep(time.Second)
+
+	w.Pause()
+	assert.True(t, w.IsPaused())
+	w.advance()
+	assert.Empty(t, order)
+
+	// Paused world can still be stepped manually
+	assert.NoError(t, w.Step(time.Second))
+	assert.Equal(t, []string{"a"}, order)
+
+	w.Resume()
+	assert.False(t, w.IsPaused())
+	w.advance()
+	assert.Equal(t, []string{"a", "a"}, order)
+}
+
+func TestTimeScale(t *testing.T) {
+	var order []string
+	system := &fakeSystem{name: "a", every: time.Second, order: &order}
+	w := Create[any](9, 9, system)
+	w.SetTimestep(time.Second)
+	assert.Equal(t, 1.0, w.TimeScale())
+
+	w.SetTimeScale(2.5)
+	assert.Equal(t, 2.5, w.TimeScale())
+	w.advance()
+	w.advance()
+	assert.Equal(t, uint64(2), system.clock.Tick)
+	assert.Equal(t, 2500*time.Millisecond, system.clock.Elapsed)
+	assert.Equal(t, time.Unix(0, 0).UTC().Add(5*time.Second), system.clock.Current)
+
+	w.SetTimeScale(0)
+	assert.Equal(t, 0.0, w.TimeScale())
+	assert.Panics(t, func() {
+		w.SetTimeScale(-1)
+	})
+}
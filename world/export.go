@@ -0,0 +1,207 @@
+package world
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/kelindar/ecs/entity/item"
+	"github.com/kelindar/ecs/entity/mobile"
+	"github.com/kelindar/ecs/entity/static"
+	"github.com/kelindar/ecs/state"
+	"github.com/kelindar/tile"
+)
+
+// gridRecord represents the dimensions of the map, in an export
+type gridRecord struct {
+	Kind   string `json:"kind"`
+	Width  int16  `json:"width"`
+	Height int16  `json:"height"`
+}
+
+// tileRecord represents a tile of the map, in an export. When imported, the terrain
+// is only used if the value is not specified.
+type tileRecord struct {
+	Kind    string      `json:"kind"`
+	At      state.Point `json:"at"`
+	Terrain string      `json:"terrain,omitempty"`
+	Value   *tile.Value `json:"value,omitempty"`
+}
+
+// Export writes the state of the world as newline-delimited JSON, starting with
+// the dimensions of the map and its tiles which are not empty, followed by every
+// entity of the collections keyed by its unique identifier. Each line is tagged
+// with its "kind", being one of "grid", "tile", "mobile", "static" or "item".
+func (w *World[T]) Export(dst io.Writer) error {
+	encoder := json.NewEncoder(dst)
+	size := w.Grid.Size
+	if err := encoder.Encode(gridRecord{Kind: "grid", Width: size.X, Height: size.Y}); err != nil {
+		return err
+	}
+
+	for y := int16(0); y < size.Y; y++ {
+		for x := int16(0); x < size.X; x++ {
+			t, _ := w.Grid.At(x, y)
+			if value := t.Value(); value != 0 {
+				if err := encoder.Encode(tileRecord{
+					Kind:    "tile",
+					At:      state.Point{X: x, Y: y},
+					Terrain: w.Terrains.Of(value).Name,
+					Value:   &value,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	var errs []error
+	encode := func(kind Kind, record any) {
+		if err := encodeTagged(dst, kind, record); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(
+		w.Mobiles.Range(func(v mobile.Mobile) { encode(KindMobile, v.Record()) }),
+		w.Statics.Range(func(v static.Static) { encode(KindStatic, v.Record()) }),
+		w.Items.Range(func(v item.Item) { encode(KindItem, v.Record()) }),
+		errors.Join(errs...),
+	)
+}
+
+// Import reads the state of the world written by Export and merges it into the
+// world. The entities are inserted or updated by their unique identifier, and a
+// new identifier is assigned to the entities which have none. Only the fields
+// present on a line are written, the others keep their current value. The map
+// must have the same dimensions as the one of the world.
+func (w *World[T]) Import(src io.Reader) error {
+	decoder := json.NewDecoder(src)
+	defer w.Terrains.version.Add(1)
+	for line := 1; ; line++ {
+		var data json.RawMessage
+		switch err := decoder.Decode(&data); {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return fmt.Errorf("world: unable to import line %d, %w", line, err)
+		}
+
+		if err := w.importRecord(data); err != nil {
+			return fmt.Errorf("world: unable to import line %d, %w", line, err)
+		}
+	}
+}
+
+// importRecord imports a single line of an export
+func (w *World[T]) importRecord(data []byte) error {
+	var tag struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &tag); err != nil {
+		return err
+	}
+
+	switch tag.Kind {
+	case "grid":
+		var r gridRecord
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+
+		if size := w.Grid.Size; r.Width != size.X || r.Height != size.Y {
+			return fmt.Errorf("map of %dx%d does not match the %dx%d map of the world", r.Width, r.Height, size.X, size.Y)
+		}
+		return nil
+
+	case "tile":
+		var r tileRecord
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+		return w.importTile(r)
+
+	case KindMobile.String():
+		return importEntity[mobile.Mobile, mobile.Record](data, w.Mobiles)
+	case KindStatic.String():
+		return importEntity[static.Static, static.Record](data, w.Statics)
+	case KindItem.String():
+		return importEntity[item.Item, item.Record](data, w.Items)
+	default:
+		return fmt.Errorf("unknown kind %q", tag.Kind)
+	}
+}
+
+// importTile writes the value of a tile, or its terrain if there is no value
+func (w *World[T]) importTile(r tileRecord) error {
+	if _, ok := w.Grid.At(r.At.X, r.At.Y); !ok {
+		return fmt.Errorf("tile %v is outside of the map", tile.Point(r.At))
+	}
+
+	switch kind, ok := w.Terrains.Find(r.Terrain); {
+	case r.Value != nil:
+		w.Grid.WriteAt(r.At.X, r.At.Y, *r.Value)
+	case ok:
+		w.Grid.MaskAt(r.At.X, r.At.Y, tile.Value(kind), terrainMask)
+	default:
+		return fmt.Errorf("unknown terrain %q", r.Terrain)
+	}
+	return nil
+}
+
+// collection represents a collection of entities which can be imported
+type collection[V any] interface {
+	Insert(fn func(v V) error) (string, error)
+	Upsert(id string, fn func(v V) error) error
+}
+
+// record represents an entity which can be converted to and from its record
+type record[V, R any] interface {
+	*V
+	Record() R
+	SetRecord(R)
+}
+
+// importEntity inserts or updates an entity from its record. The record is decoded
+// on top of the current one, so that the fields missing from the line are kept.
+func importEntity[V, R any, P record[V, R]](data []byte, c collection[V]) error {
+	var key struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(data, &key); err != nil {
+		return err
+	}
+
+	merge := func(v V) error {
+		r := P(&v).Record()
+		if err := json.Unmarshal(data, &r); err != nil {
+			return err
+		}
+
+		P(&v).SetRecord(r)
+		return nil
+	}
+
+	if key.ID == "" {
+		_, err := c.Insert(merge)
+		return err
+	}
+
+	return c.Upsert(key.ID, merge)
+}
+
+// encodeTagged writes a record as a line of JSON, tagged with the kind of entity
+func encodeTagged(dst io.Writer, kind Kind, record any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	line := make([]byte, 0, len(data)+16)
+	line = fmt.Appendf(line, `{"kind":%q,`, kind)
+	line = append(line, data[1:]...)
+	line = append(line, '\n')
+	_, err = dst.Write(line)
+	return err
+}
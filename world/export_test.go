@@ -0,0 +1,94 @@
+package world
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kelindar/ecs/entity/mobile"
+	"github.com/kelindar/ecs/state"
+	"github.com/kelindar/tile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport(t *testing.T) {
+	w := Create[any](9, 9)
+	defer w.Close()
+
+	assert.True(t, w.SetTerrain(tile.At(2, 3), TerrainWall))
+	id, err := w.Mobiles.Insert(func(v mobile.Mobile) error {
+		v.SetLocation(tile.At(1, 1))
+		v.SetMovement(state.NewMovement(tile.East, 5, time.Second, 400*time.Millisecond))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	var buffer bytes.Buffer
+	assert.NoError(t, w.Export(&buffer))
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, `{"kind":"grid","width":9,"height":9}`, lines[0])
+	assert.Equal(t, `{"kind":"tile","at":{"x":2,"y":3},"terrain":"wall","value":1}`, lines[1])
+	assert.Contains(t, lines[2], `{"kind":"mobile","id":"`+id+`"`)
+	assert.Contains(t, lines[2], `"at":{"x":1,"y":1}`)
+	assert.Contains(t, lines[2], `"direction":"east","distance":5,"velocity":"1s","duration":"400ms"`)
+
+	// Import into an empty world
+	out := Create[any](9, 9)
+	defer out.Close()
+	assert.NoError(t, out.Import(&buffer))
+	assert.Equal(t, []Entity{{KindMobile, 0}}, out.EntitiesAt(tile.At(1, 1)))
+	wall, _ := out.TerrainAt(tile.At(2, 3))
+	assert.Equal(t, "wall", wall.Name)
+	assert.NoError(t, out.Mobiles.Get(id, func(v mobile.Mobile) error {
+		assert.Equal(t, tile.At(1, 1), v.Location())
+		assert.Equal(t, tile.East, v.Movement().Direction())
+		return nil
+	}))
+}
+
+func TestImport(t *testing.T) {
+	w := Create[any](9, 9)
+	defer w.Close()
+
+	// Terrain by its name, entities with and without an identifier
+	assert.NoError(t, w.Import(strings.NewReader(`
+		{"kind":"tile","at":{"x":4,"y":4},"terrain":"water"}
+		{"kind":"mobile","id":"abc","at":{"x":1,"y":2}}
+		{"kind":"mobile","at":{"x":3,"y":3}}
+		{"kind":"mobile","id":"abc","at":{"x":2,"y":2}}
+	`)))
+	water, _ := w.TerrainAt(tile.At(4, 4))
+	assert.Equal(t, "water", water.Name)
+	assert.Equal(t, 2, w.Mobiles.Count())
+	assert.NoError(t, w.Mobiles.Get("abc", func(v mobile.Mobile) error {
+		assert.Equal(t, tile.At(2, 2), v.Location())
+		return nil
+	}))
+
+	// A partial record only updates the fields it contains
+	assert.NoError(t, w.Mobiles.UpdateByID("abc", func(v mobile.Mobile) error {
+		v.SetMovement(state.NewMovement(tile.East, 5, time.Second, 0))
+		v.SetTeam(3)
+		return nil
+	}))
+	assert.NoError(t, w.Import(strings.NewReader(`{"kind":"mobile","id":"abc","at":{"x":5,"y":5}}`)))
+	assert.NoError(t, w.Mobiles.Get("abc", func(v mobile.Mobile) error {
+		assert.Equal(t, tile.At(5, 5), v.Location())
+		assert.Equal(t, tile.East, v.Movement().Direction())
+		assert.Equal(t, uint16(3), v.Team())
+		return nil
+	}))
+
+	for _, input := range []string{
+		`{"kind":"grid","width":10,"height":9}`,
+		`{"kind":"tile","at":{"x":10,"y":1},"terrain":"wall"}`,
+		`{"kind":"tile","at":{"x":1,"y":1},"terrain":"lava"}`,
+		`{"kind":"player"}`,
+		`{"kind":"mobile","at":"here"}`,
+		`{"kind":`,
+	} {
+		assert.Error(t, w.Import(strings.NewReader(input)), input)
+	}
+}
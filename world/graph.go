@@ -0,0 +1,108 @@
+package world
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sortJobs orders the jobs topologically, first by their phase and then by their
+// declared dependencies. Jobs that are not constrained keep their registration
+// order. It returns an error if the dependencies contain a cycle or contradict
+// the phases of the systems.
+func sortJobs[T comparable](jobs []*job[T]) ([]*job[T], error) {
+	byName := make(map[string][]int, len(jobs))
+	for i, job := range jobs {
+		byName[job.name] = append(byName[job.name], i)
+	}
+
+	// Build the edges of the dependency graph, from a job to its dependents
+	edges := make([][]int, len(jobs))
+	for i, job := range jobs {
+		dependent, ok := job.System.(Dependent)
+		if !ok {
+			continue
+		}
+
+		for _, name := range dependent.After() {
+			for _, j := range byName[name] {
+				edges[j] = append(edges[j], i)
+			}
+		}
+
+		for _, name := range dependent.Before() {
+			edges[i] = append(edges[i], byName[name]...)
+		}
+	}
+
+	// Validate that the dependencies do not contradict the phases
+	degree := make([]int, len(jobs))
+	for i, targets := range edges {
+		for _, j := range targets {
+			if jobs[i].phase > jobs[j].phase {
+				return nil, fmt.Errorf("world: system %s (%s) cannot run before %s (%s)",
+					jobs[i].name, jobs[i].phase, jobs[j].name, jobs[j].phase)
+			}
+			degree[j]++
+		}
+	}
+
+	// Repeatedly pick the earliest job that has all of its dependencies satisfied
+	sorted := make([]*job[T], 0, len(jobs))
+	done := make([]bool, len(jobs))
+	for len(sorted) < len(jobs) {
+		next := -1
+		for i, job := range jobs {
+			if !done[i] && degree[i] == 0 && (next < 0 || job.phase < jobs[next].phase) {
+				next = i
+			}
+		}
+
+		if next < 0 {
+			return nil, fmt.Errorf("world: dependency cycle between systems %s", pending(jobs, done))
+		}
+
+		done[next] = true
+		sorted = append(sorted, jobs[next])
+		for _, j := range edges[next] {
+			degree[j]--
+		}
+	}
+
+	return sorted, nil
+}
+
+// pending returns the names of the jobs that were not sorted
+func pending[T comparable](jobs []*job[T], done []bool) string {
+	names := make([]string, 0, len(jobs))
+	for i, job := range jobs {
+		if !done[i] {
+			names = append(names, job.name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// batchJobs splits the sorted jobs into consecutive batches of jobs that do not
+// conflict with one another and can be updated concurrently.
+func batchJobs[T comparable](jobs []*job[T]) [][]*job[T] {
+	var batches [][]*job[T]
+	for _, j := range jobs {
+		if n := len(batches); n > 0 && !conflictsWith(j, batches[n-1]) {
+			batches[n-1] = append(batches[n-1], j)
+			continue
+		}
+
+		batches = append(batches, []*job[T]{j})
+	}
+	return batches
+}
+
+// conflictsWith returns whether a job conflicts with any job of the batch
+func conflictsWith[T comparable](job *job[T], batch []*job[T]) bool {
+	for _, other := range batch {
+		if job.conflicts(other) {
+			return true
+		}
+	}
+	return false
+}
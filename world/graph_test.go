@@ -0,0 +1,121 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortJobs(t *testing.T) {
+	var order []string
+	w := Create[any](9, 9,
+		&orderedSystem{fakeSystem{name: "persist", order: &order}, PhasePersist, nil, nil},
+		&orderedSystem{fakeSystem{name: "combat", order: &order}, PhaseUpdate, []string{"movement"}, nil},
+		&orderedSystem{fakeSystem{name: "movement", order: &order}, PhaseUpdate, []string{"input"}, nil},
+		&orderedSystem{fakeSystem{name: "input", order: &order}, PhaseUpdate, nil, []string{"unknown"}},
+		&orderedSystem{fakeSystem{name: "prepare", order: &order}, PhasePreUpdate, nil, nil},
+	)
+
+	assert.NoError(t, w.Step(0))
+	assert.Equal(t, []string{"prepare", "input", "movement", "combat", "persist"}, order)
+}
+
+func TestSortJobsCycle(t *testing.T) {
+	_, err := sortJobs([]*job[any]{
+		newJob[any](&orderedSystem{fakeSystem{name: "a"}, PhaseUpdate, []string{"b"}, nil}),
+		newJob[any](&orderedSystem{fakeSystem{name: "b"}, PhaseUpdate, []string{"c"}, nil}),
+		newJob[any](&orderedSystem{fakeSystem{name: "c"}, PhaseUpdate, []string{"a"}, nil}),
+	})
+	assert.ErrorContains(t, err, "cycle")
+}
+
+func TestSortJobsPhase(t *testing.T) {
+	_, err := sortJobs([]*job[any]{
+		newJob[any](&orderedSystem{fakeSystem{name: "a"}, PhasePersist, nil, []string{"b"}}),
+		newJob[any](&orderedSystem{fakeSystem{name: "b"}, PhaseUpdate, nil, nil}),
+	})
+	assert.ErrorContains(t, err, "cannot run before")
+}
+
+func TestBatchJobs(t *testing.T) {
+	access := func(name string, reads, writes []string) *job[any] {
+		return newJob[any](&accessSystem{fakeSystem{name: name}, Access{Reads: reads, Writes: writes}})
+	}
+
+	batches := batchJobs([]*job[any]{
+		access("a", []string{"grid"}, []string{"mobiles.at"}),
+		access("b", []string{"grid"}, []string{"items"}),
+		access("c", []string{"mobiles"}, nil),
+		newJob[any](&fakeSystem{name: "d"}),
+		access("e", nil, []string{"statics"}),
+		access("f", []string{"mobiles.move"}, nil),
+	})
+
+	var names [][]string
+	for _, batch := range batches {
+		var group []string
+		for _, job := range batch {
+			group = append(group, job.name)
+		}
+		names = append(names, group)
+	}
+
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}, {"d"}, {"e", "f"}}, names)
+}
+
+func TestAccessConflicts(t *testing.T) {
+	tests := []struct {
+		a, b     Access
+		conflict bool
+	}{
+		{Access{Reads: []string{"mobiles"}}, Access{Reads: []string{"mobiles"}}, false},
+		{Access{Writes: []string{"mobiles.at"}}, Access{Writes: []string{"mobiles.move"}}, false},
+		{Access{Writes: []string{"mobiles.at"}}, Access{Reads: []string{"mobiles"}}, true},
+		{Access{Reads: []string{"mobiles.at"}}, Access{Writes: []string{"mobiles"}}, true},
+		{Access{Writes: []string{"grid"}}, Access{Writes: []string{"grid"}}, true},
+		{Access{Writes: []string{"mobile"}}, Access{Writes: []string{"mobiles"}}, false},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.conflict, tc.a.conflicts(&tc.b))
+		assert.Equal(t, tc.conflict, tc.b.conflicts(&tc.a))
+	}
+}
+
+func TestPhaseString(t *testing.T) {
+	assert.Equal(t, "pre-update", PhasePreUpdate.String())
+	assert.Equal(t, "update", PhaseUpdate.String())
+	assert.Equal(t, "post-update", PhasePostUpdate.String())
+	assert.Equal(t, "persist", PhasePersist.String())
+	assert.Equal(t, "phase(9)", Phase(9).String())
+}
+
+// ---------------------------------- Test system ----------------------------------
+
+type orderedSystem struct {
+	fakeSystem
+	phase  Phase
+	after  []string
+	before []string
+}
+
+func (s *orderedSystem) Phase() Phase {
+	return s.phase
+}
+
+func (s *orderedSystem) After() []string {
+	return s.after
+}
+
+func (s *orderedSystem) Before() []string {
+	return s.before
+}
+
+type accessSystem struct {
+	fakeSystem
+	access Access
+}
+
+func (s *accessSystem) Access() Access {
+	return s.access
+}
@@ -0,0 +1,237 @@
+package world
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/kelindar/ecs/internal/atomicfile"
+	"go.uber.org/multierr"
+)
+
+// Retention represents the rules for keeping the past generations of the saves.
+// A generation is kept if any of the rules retains it, and none are kept if all
+// of the rules are zero.
+type Retention struct {
+	Recent int // The number of most recent generations to keep
+	Hourly int // The number of most recent hours to keep the last generation of
+	Daily  int // The number of most recent days to keep the last generation of
+}
+
+// Generation represents a past generation of the saves, kept in the history
+type Generation struct {
+	Number uint64    // The sequence number of the save
+	Time   time.Time // The time of the save
+	dir    string    // The directory of the generation
+}
+
+// History returns the past generations of the saves which are kept, ordered from
+// the oldest to the most recent one.
+func (w *World[T]) History() ([]Generation, error) {
+	entries, err := os.ReadDir(w.pathOf(w.options.Layout.History))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	history := make([]Generation, 0, len(entries))
+	for _, entry := range entries {
+		dir := filepath.Join(w.pathOf(w.options.Layout.History), entry.Name())
+		if m, err := readManifestAt(filepath.Join(dir, w.options.Layout.Manifest)); err == nil && entry.IsDir() {
+			history = append(history, Generation{Number: m.Generation, Time: m.Time, dir: dir})
+		}
+	}
+
+	slices.SortFunc(history, func(a, b Generation) int {
+		return a.Time.Compare(b.Time)
+	})
+	return history, nil
+}
+
+// archive keeps the files of a save in the history and removes the generations
+// which are no longer retained. The files are linked rather than copied, since
+// the next save replaces them instead of writing over them.
+func (w *World[T]) archive(m manifest) error {
+	if w.options.History == (Retention{}) {
+		return nil
+	}
+
+	name := fmt.Sprintf("%s-%06d", m.Time.Format("20060102T150405Z"), m.Generation)
+	dir := filepath.Join(w.pathOf(w.options.Layout.History), name)
+	for _, file := range m.Files {
+		if err := linkOrCopy(w.pathOf(file), filepath.Join(dir, file)); err != nil {
+			return err
+		}
+	}
+
+	// The manifest is written last, marking the generation as complete
+	if err := writeManifestAt(filepath.Join(dir, w.options.Layout.Manifest), m); err != nil {
+		return err
+	}
+
+	return w.prune()
+}
+
+// prune removes the generations which are no longer retained, along with the
+// ones which were only partially archived.
+func (w *World[T]) prune() error {
+	history, err := w.History()
+	if err != nil {
+		return err
+	}
+
+	kept := make(map[string]bool, len(history))
+	for _, generation := range w.options.History.keep(history) {
+		kept[generation.dir] = true
+	}
+
+	entries, err := os.ReadDir(w.pathOf(w.options.Layout.History))
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if dir := filepath.Join(w.pathOf(w.options.Layout.History), entry.Name()); !kept[dir] {
+			if err := os.RemoveAll(dir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rollback replaces the save with the most recent generation kept in the history
+// at the specified time. The commits logged since the last save are discarded,
+// since they follow the generation being restored.
+func (w *World[T]) rollback(at time.Time) error {
+	history, err := w.History()
+	if err != nil {
+		return err
+	}
+
+	idx := -1
+	for i, generation := range history {
+		if !generation.Time.After(at) {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return fmt.Errorf("world: no saved generation at %v", at)
+	}
+
+	generation := history[idx]
+	from, err := readManifestAt(filepath.Join(generation.dir, w.options.Layout.Manifest))
+	if err != nil {
+		return err
+	}
+
+	// Stage the files of the generation, keeping the current save and its logs intact
+	for _, file := range from.Files {
+		if err := atomicfile.Stage(w.pathOf(file), copyOf(filepath.Join(generation.dir, file))); err != nil {
+			return multierr.Append(err, w.discard(from.Files))
+		}
+	}
+
+	// Commit the files as a new save, which also commits to discarding the logs
+	m := manifest{
+		Generation: w.generation + 1,
+		Time:       time.Now().UTC(),
+		Files:      from.Files,
+		Staged:     true,
+		Rollback:   true,
+	}
+	if err := w.writeManifest(m); err != nil {
+		return multierr.Append(err, w.discard(from.Files))
+	}
+
+	w.generation = m.Generation
+	if err := w.replace(m.Files); err != nil {
+		return err
+	}
+
+	// The logs follow the generation being restored, so they must never be replayed
+	if err := w.removeLogs(); err != nil {
+		return err
+	}
+
+	w.logger.Warn("rolled back to a past generation", "generation", generation.Number, "time", generation.Time)
+	m.Staged, m.Rollback = false, false
+	return w.writeManifest(m)
+}
+
+// removeLogs removes all of the segments of the write-ahead logs
+func (w *World[T]) removeLogs() error {
+	layout := w.options.Layout
+	for _, name := range []string{layout.Mobiles, layout.Statics, layout.Items} {
+		segments, err := filepath.Glob(w.pathOf(logOf(name)) + ".*")
+		if err != nil {
+			return err
+		}
+
+		for _, segment := range segments {
+			if err := os.Remove(segment); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// keep returns the generations which are retained by the rules
+func (r Retention) keep(history []Generation) []Generation {
+	hours := make(map[time.Time]bool, r.Hourly)
+	days := make(map[time.Time]bool, r.Daily)
+	kept := make([]Generation, 0, len(history))
+	for i, generation := range slices.Backward(history) {
+		hour := generation.Time.UTC().Truncate(time.Hour)
+		day := generation.Time.UTC().Truncate(24 * time.Hour)
+		recent := len(history)-1-i < r.Recent
+		hourly := !hours[hour] && len(hours) < r.Hourly
+		daily := !days[day] && len(days) < r.Daily
+		if hourly {
+			hours[hour] = true
+		}
+		if daily {
+			days[day] = true
+		}
+		if recent || hourly || daily {
+			kept = append(kept, generation)
+		}
+	}
+
+	slices.Reverse(kept)
+	return kept
+}
+
+// linkOrCopy links the file into the destination, or copies it if the file
+// system does not support links.
+func linkOrCopy(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+
+	switch err := os.Link(src, dst); {
+	case err == nil || os.IsExist(err):
+		return nil
+	case os.IsNotExist(err):
+		return err
+	}
+
+	return atomicfile.WriteFile(dst, copyOf(src))
+}
+
+// copyOf returns a function which writes the content of the file
+func copyOf(filename string) func(io.Writer) error {
+	return func(dst io.Writer) error {
+		file, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+
+		defer file.Close()
+		_, err = io.Copy(dst, file)
+		return err
+	}
+}
@@ -0,0 +1,104 @@
+package world
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kelindar/tile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetention(t *testing.T) {
+	start := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	var history []Generation
+	for _, offset := range []time.Duration{
+		// day 1, 10:00
+		0, 30 * time.Minute,
+		// day 1, 11:00
+		time.Hour, 90 * time.Minute,
+		// day 2, 10:00
+		24 * time.Hour,
+		// day 2, 11:00
+		25 * time.Hour, 25*time.Hour + 10*time.Minute, 25*time.Hour + 20*time.Minute,
+	} {
+		history = append(history, Generation{Number: uint64(len(history) + 1), Time: start.Add(offset)})
+	}
+
+	numbers := func(generations []Generation) (out []uint64) {
+		for _, g := range generations {
+			out = append(out, g.Number)
+		}
+		return
+	}
+
+	assert.Empty(t, Retention{}.keep(history))
+	assert.Equal(t, []uint64{7, 8}, numbers(Retention{Recent: 2}.keep(history)))
+	assert.Equal(t, []uint64{5, 8}, numbers(Retention{Hourly: 2}.keep(history)))
+	assert.Equal(t, []uint64{4, 8}, numbers(Retention{Daily: 5}.keep(history)))
+	assert.Equal(t, []uint64{4, 5, 7, 8}, numbers(Retention{Recent: 2, Hourly: 2, Daily: 2}.keep(history)))
+}
+
+func TestHistory(t *testing.T) {
+	defer os.RemoveAll("temp")
+	w, err := OpenWith[any]("temp", Options{History: Retention{Recent: 2}})
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, w.Save())
+	}
+	assert.NoError(t, w.Close())
+
+	history, err := w.History()
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, uint64(3), history[0].Number)
+	assert.Equal(t, uint64(4), history[1].Number)
+
+	entries, err := os.ReadDir("temp/history")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestOpenAt(t *testing.T) {
+	defer os.RemoveAll("temp")
+	options := Options{
+		History: Retention{Recent: 5},
+		Layout:  Layout{Mobiles: "npcs.bin", History: "archive"},
+	}
+
+	w, err := OpenWith[any]("temp", options)
+	assert.NoError(t, err)
+
+	// Save a generation with one, then two mobiles, and log a third one
+	for _, at := range []tile.Point{tile.At(1, 1), tile.At(2, 2), tile.At(3, 3)} {
+		insertMobile(t, w, at)
+		if at != tile.At(3, 3) {
+			assert.NoError(t, w.Save())
+		}
+	}
+	assert.NoError(t, w.Close())
+
+	history, err := w.History()
+	assert.NoError(t, err)
+	assert.Len(t, history, 3)
+
+	// Nothing was saved before the first generation
+	_, err = OpenAt[any]("temp", options, history[0].Time.Add(-time.Second))
+	assert.Error(t, err)
+
+	// Roll back to the generation with a single mobile
+	w, err = OpenAt[any]("temp", options, history[1].Time.Add(time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, w.Mobiles.Count())
+	assert.Equal(t, []Entity{{KindMobile, 0}}, w.EntitiesAt(tile.At(1, 1)))
+	assert.Empty(t, w.EntitiesAt(tile.At(3, 3)))
+	assert.NoError(t, w.Close())
+	assert.NoFileExists(t, "temp/mobiles.bin")
+
+	// The roll back is kept when opened again
+	w, err = OpenWith[any]("temp", options)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, w.Mobiles.Count())
+	assert.Equal(t, uint64(4), w.generation)
+	assert.NoError(t, w.Close())
+}
@@ -0,0 +1,169 @@
+package world
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/kelindar/tile"
+)
+
+// EventType represents the type of a change within a region of interest
+type EventType uint8
+
+// Various types of changes within a region of interest
+const (
+	EventEnter  EventType = iota // The entity has entered the region, or was inserted in it
+	EventLeave                   // The entity has left the region, or was deleted from it
+	EventUpdate                  // The entity has moved within the region
+)
+
+// String returns the name of the event type
+func (t EventType) String() string {
+	switch t {
+	case EventEnter:
+		return "enter"
+	case EventLeave:
+		return "leave"
+	case EventUpdate:
+		return "update"
+	default:
+		return fmt.Sprintf("event(%d)", t)
+	}
+}
+
+// Event represents a change of an entity within a region of interest
+type Event struct {
+	Type   EventType  // The type of the change
+	Entity Entity     // The entity which has changed
+	From   tile.Point // The previous location, unless the entity was inserted
+	To     tile.Point // The current location, unless the entity was deleted
+}
+
+// Subscription represents a subscription to the changes within a region of interest
+type Subscription struct {
+	index     *index      // The spatial index the subscription is registered on
+	region    tile.Rect   // The region of interest, guarded by the index lock
+	fn        func(Event) // The callback to notify
+	cancelled atomic.Bool // Whether the subscription was cancelled
+}
+
+// notification represents an event to be dispatched to a subscription
+type notification struct {
+	sub   *Subscription
+	event Event
+}
+
+// Subscribe subscribes to the entities of every collection entering, leaving or
+// moving within the region of interest, excluding its maximum edges. The entities
+// already within the region are notified as entering it right away. The changes
+// are followed through the commits of the collections, hence the callback is
+// invoked while the changes are committed and must not access the collections.
+func (w *World[T]) Subscribe(region tile.Rect, fn func(Event)) *Subscription {
+	sub := &Subscription{
+		index:  w.space,
+		region: region,
+		fn:     fn,
+	}
+
+	x := w.space
+	x.lock.Lock()
+	x.subs = append(x.subs, sub)
+	x.visit(region, func(at tile.Point, entities []Entity) {
+		for _, entity := range entities {
+			x.pending = append(x.pending, notification{sub, Event{Type: EventEnter, Entity: entity, To: at}})
+		}
+	})
+
+	pending := x.flush()
+	x.lock.Unlock()
+	dispatch(pending)
+	return sub
+}
+
+// Region returns the region of interest of the subscription
+func (s *Subscription) Region() tile.Rect {
+	s.index.lock.RLock()
+	defer s.index.lock.RUnlock()
+	return s.region
+}
+
+// Resize changes the region of interest of the subscription. The entities which
+// are no longer within the region are notified as leaving it, while the ones that
+// are now within the region are notified as entering it.
+func (s *Subscription) Resize(region tile.Rect) {
+	x := s.index
+	x.lock.Lock()
+	prev := s.region
+	s.region = region
+	x.visit(prev, func(at tile.Point, entities []Entity) {
+		if !region.Contains(at) {
+			for _, entity := range entities {
+				x.pending = append(x.pending, notification{s, Event{Type: EventLeave, Entity: entity, From: at, To: at}})
+			}
+		}
+	})
+
+	x.visit(region, func(at tile.Point, entities []Entity) {
+		if !prev.Contains(at) {
+			for _, entity := range entities {
+				x.pending = append(x.pending, notification{s, Event{Type: EventEnter, Entity: entity, From: at, To: at}})
+			}
+		}
+	})
+
+	pending := x.flush()
+	x.lock.Unlock()
+	dispatch(pending)
+}
+
+// Cancel cancels the subscription, no further changes are notified
+func (s *Subscription) Cancel() {
+	x := s.index
+	x.lock.Lock()
+	defer x.lock.Unlock()
+	for i, sub := range x.subs {
+		if sub == s {
+			x.subs = append(x.subs[:i], x.subs[i+1:]...)
+			break
+		}
+	}
+	s.cancelled.Store(true)
+}
+
+// notify queues the events for the subscriptions whose region is affected by
+// a change of location of an entity.
+func (x *index) notify(entity Entity, from tile.Point, existed bool, to tile.Point, exists bool) {
+	for _, sub := range x.subs {
+		before := existed && sub.region.Contains(from)
+		after := exists && sub.region.Contains(to)
+		event := Event{Entity: entity, From: from, To: to}
+		switch {
+		case before && after:
+			event.Type = EventUpdate
+		case after:
+			event.Type = EventEnter
+		case before:
+			event.Type = EventLeave
+		default:
+			continue
+		}
+
+		x.pending = append(x.pending, notification{sub, event})
+	}
+}
+
+// flush takes the pending notifications, must be called while the index is locked
+func (x *index) flush() []notification {
+	pending := x.pending
+	x.pending = nil
+	return pending
+}
+
+// dispatch invokes the callbacks of the subscriptions which are still active
+func dispatch(pending []notification) {
+	for _, n := range pending {
+		if !n.sub.cancelled.Load() {
+			n.sub.fn(n.event)
+		}
+	}
+}
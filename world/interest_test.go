@@ -0,0 +1,87 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/kelindar/ecs/entity/item"
+	"github.com/kelindar/ecs/entity/mobile"
+	"github.com/kelindar/tile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribe(t *testing.T) {
+	w := Create[any](9, 9)
+	defer w.Close()
+	insertMobile(t, w, tile.At(1, 1))
+
+	// The entities already within the region are entering it
+	var events []Event
+	sub := w.Subscribe(tile.NewRect(0, 0, 3, 3), func(e Event) {
+		events = append(events, e)
+	})
+	assert.Equal(t, []Event{
+		{Type: EventEnter, Entity: Entity{KindMobile, 0}, To: tile.At(1, 1)},
+	}, events)
+
+	// Insert within and outside of the region
+	events = events[:0]
+	insertMobile(t, w, tile.At(2, 2))
+	insertMobile(t, w, tile.At(5, 5))
+	assert.Equal(t, []Event{
+		{Type: EventEnter, Entity: Entity{KindMobile, 1}, To: tile.At(2, 2)},
+	}, events)
+
+	// Move all of the mobiles, as the movement system does
+	events = events[:0]
+	assert.NoError(t, w.Mobiles.Range(func(v mobile.Mobile) {
+		v.SetLocation(tile.At(v.Location().X+1, v.Location().Y))
+	}))
+	assert.ElementsMatch(t, []Event{
+		{Type: EventUpdate, Entity: Entity{KindMobile, 0}, From: tile.At(1, 1), To: tile.At(2, 1)},
+		{Type: EventLeave, Entity: Entity{KindMobile, 1}, From: tile.At(2, 2), To: tile.At(3, 2)},
+	}, events)
+
+	// Delete from the region
+	events = events[:0]
+	assert.True(t, w.Mobiles.DeleteAt(0))
+	assert.Equal(t, []Event{
+		{Type: EventLeave, Entity: Entity{KindMobile, 0}, From: tile.At(2, 1)},
+	}, events)
+
+	// Once cancelled, nothing is notified
+	events = events[:0]
+	sub.Cancel()
+	insertMobile(t, w, tile.At(1, 1))
+	assert.Empty(t, events)
+}
+
+func TestSubscribeResize(t *testing.T) {
+	w := Create[any](9, 9)
+	defer w.Close()
+	insertMobile(t, w, tile.At(1, 1))
+	_, err := w.Items.Insert(func(v item.Item) error {
+		v.SetLocation(tile.At(4, 1))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	var events []Event
+	sub := w.Subscribe(tile.NewRect(0, 0, 3, 3), func(e Event) {
+		events = append(events, e)
+	})
+
+	events = events[:0]
+	sub.Resize(tile.NewRect(3, 0, 6, 3))
+	assert.Equal(t, tile.NewRect(3, 0, 6, 3), sub.Region())
+	assert.Equal(t, []Event{
+		{Type: EventLeave, Entity: Entity{KindMobile, 0}, From: tile.At(1, 1), To: tile.At(1, 1)},
+		{Type: EventEnter, Entity: Entity{KindItem, 0}, From: tile.At(4, 1), To: tile.At(4, 1)},
+	}, events)
+}
+
+func TestEventTypeString(t *testing.T) {
+	assert.Equal(t, "enter", EventEnter.String())
+	assert.Equal(t, "leave", EventLeave.String())
+	assert.Equal(t, "update", EventUpdate.String())
+	assert.Equal(t, "event(9)", EventType(9).String())
+}
@@ -0,0 +1,80 @@
+package world
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/kelindar/ecs/entity"
+)
+
+// Options represents the configuration of a world
+type Options struct {
+	Width      int16         // The width of the map, in tiles (default: 3072)
+	Height     int16         // The height of the map, in tiles (default: 3072)
+	Layout     Layout        // The layout of the save directory
+	Migrations Migrations    // The upgrades of the saves written with older schemas
+	History    Retention     // The past generations of the saves to keep (default: none)
+	Autosave   time.Duration // The interval between autosaves (default: 60s)
+	Timestep   time.Duration // The fixed timestep, or zero for real-time mode
+	Handler    slog.Handler  // The log handler to use (default: slog default handler)
+	Shard      string        // The shard identifier, attached to every log record
+}
+
+// Layout represents the names of the save files, relative to the save directory
+type Layout struct {
+	Manifest string // The manifest of the last save (default: "manifest.json")
+	Grid     string // The map file (default: "grid.bin")
+	Mobiles  string // The mobiles collection file (default: "mobiles.bin")
+	Statics  string // The statics collection file (default: "statics.bin")
+	Items    string // The items collection file (default: "items.bin")
+	History  string // The directory of the past generations (default: "history")
+}
+
+// Migrations represents the registry of the upgrades of each collection, applied
+// while opening a world saved with an older version of the collection schema.
+type Migrations struct {
+	Mobiles []entity.Migration // The upgrades of the mobiles collection
+	Statics []entity.Migration // The upgrades of the statics collection
+	Items   []entity.Migration // The upgrades of the items collection
+}
+
+// withDefaults returns the options, with the default values applied
+func (o Options) withDefaults() Options {
+	if o.Width <= 0 {
+		o.Width = 3072
+	}
+	if o.Height <= 0 {
+		o.Height = 3072
+	}
+	if o.Autosave <= 0 {
+		o.Autosave = 60 * time.Second
+	}
+	if o.Handler == nil {
+		o.Handler = slog.Default().Handler()
+	}
+	o.Layout = o.Layout.withDefaults()
+	return o
+}
+
+// withDefaults returns the layout, with the default file names applied
+func (l Layout) withDefaults() Layout {
+	if l.Manifest == "" {
+		l.Manifest = "manifest.json"
+	}
+	if l.Grid == "" {
+		l.Grid = "grid.bin"
+	}
+	if l.Mobiles == "" {
+		l.Mobiles = "mobiles.bin"
+	}
+	if l.Statics == "" {
+		l.Statics = "statics.bin"
+	}
+	if l.Items == "" {
+		l.Items = "items.bin"
+	}
+	if l.History == "" {
+		l.History = "history"
+	}
+	return l
+}
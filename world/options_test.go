@@ -0,0 +1,109 @@
+package world
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenWith(t *testing.T) {
+	defer os.RemoveAll("temp")
+	options := Options{
+		Width:    30,
+		Height:   60,
+		Autosave: time.Hour,
+		Layout: Layout{
+			Mobiles: "entities/mobiles.bin",
+			Items:   "entities/items.bin",
+		},
+	}
+
+	{ // Create
+		w, err := OpenWith[any]("temp", options)
+		assert.NoError(t, err)
+		assert.Equal(t, int16(30), w.Grid.Size.X)
+		assert.Equal(t, int16(60), w.Grid.Size.Y)
+		assert.Equal(t, time.Hour, w.Options().Autosave)
+		assert.NoError(t, w.Save())
+		assert.NoError(t, w.Close())
+	}
+
+	for _, file := range []string{"manifest.json", "grid.bin", "statics.bin", "entities/mobiles.bin", "entities/items.bin"} {
+		assert.FileExists(t, filepath.Join("temp", file))
+	}
+
+	{ // Restore, size is kept from the save
+		w, err := OpenWith[any]("temp", Options{Layout: options.Layout})
+		assert.NoError(t, err)
+		assert.Equal(t, int16(30), w.Grid.Size.X)
+		assert.Equal(t, int16(60), w.Grid.Size.Y)
+		assert.Equal(t, int16(30), w.Options().Width)
+		assert.Equal(t, int16(60), w.Options().Height)
+		assert.NoError(t, w.Close())
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var out bytes.Buffer
+	var order []string
+	system := &loggedSystem{fakeSystem: fakeSystem{name: "a", order: &order, fail: errors.New("boom")}}
+	w, err := OpenWith("temp", Options{
+		Width:   9,
+		Height:  9,
+		Shard:   "eu-1",
+		Handler: slog.NewJSONHandler(&out, nil),
+	}, System[any](system))
+	defer os.RemoveAll("temp")
+
+	assert.NoError(t, err)
+	assert.NotNil(t, system.logger)
+	assert.Error(t, w.Step(time.Second))
+
+	var records []map[string]any
+	for _, line := range bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n")) {
+		var record map[string]any
+		assert.NoError(t, json.Unmarshal(line, &record))
+		records = append(records, record)
+	}
+
+	last := records[len(records)-1]
+	assert.Equal(t, "system update failed", last["msg"])
+	assert.Equal(t, "eu-1", last["shard"])
+	assert.Equal(t, "a", last["system"])
+	assert.Equal(t, float64(1), last["tick"])
+	assert.Equal(t, "boom", last["error"])
+}
+
+func TestOptionsDefaults(t *testing.T) {
+	options := Options{}.withDefaults()
+	assert.Equal(t, int16(3072), options.Width)
+	assert.Equal(t, int16(3072), options.Height)
+	assert.Equal(t, 60*time.Second, options.Autosave)
+	assert.NotNil(t, options.Handler)
+	assert.Equal(t, Layout{
+		Manifest: "manifest.json",
+		Grid:     "grid.bin",
+		Mobiles:  "mobiles.bin",
+		Statics:  "statics.bin",
+		Items:    "items.bin",
+		History:  "history",
+	}, options.Layout)
+}
+
+// ---------------------------------- Test system ----------------------------------
+
+type loggedSystem struct {
+	fakeSystem
+	logger *slog.Logger
+}
+
+func (s *loggedSystem) SetLogger(logger *slog.Logger) {
+	s.logger = logger
+}
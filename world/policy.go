@@ -0,0 +1,93 @@
+package world
+
+import (
+	"fmt"
+)
+
+// Action represents the action taken when a system keeps failing
+type Action uint8
+
+// Various actions that can be taken when a system keeps failing
+const (
+	ActionContinue Action = iota // Keep updating the system, default
+	ActionDisable                // Stop updating the failing system
+	ActionStop                   // Stop the simulation of the entire world
+)
+
+// Policy represents the error policy of a system, specifying how the world reacts
+// to the errors returned by the system updates, as well as recovered panics.
+type Policy struct {
+	Action    Action // The action to take once the threshold is reached
+	Threshold int    // The number of consecutive failures before acting
+}
+
+// Supervised represents an optional contract for a system that declares its own
+// error policy. Systems that do not implement it are kept running on failure.
+type Supervised interface {
+	Policy() Policy
+}
+
+// SetPolicy overrides the error policy of the systems with the specified name.
+func (w *World[T]) SetPolicy(name string, policy Policy) {
+	w.sched.Lock()
+	defer w.sched.Unlock()
+	for _, job := range w.jobs {
+		if job.name == name {
+			job.policy.Store(&policy)
+		}
+	}
+}
+
+// OnError registers a callback which is invoked with the name of the system and
+// the error every time a system update fails. This must be called before Simulate.
+func (w *World[T]) OnError(fn func(system string, err error)) {
+	w.onError = append(w.onError, fn)
+}
+
+// supervise keeps track of the consecutive failures of a job and applies its
+// error policy once the threshold is reached.
+func (w *World[T]) supervise(job *job[T], err error) {
+	if err == nil {
+		job.failed.Store(0)
+		return
+	}
+
+	for _, fn := range w.onError {
+		fn(job.name, err)
+	}
+
+	policy := job.policy.Load()
+	if failures := job.failed.Add(1); int(failures) < max(1, policy.Threshold) {
+		return
+	}
+
+	switch policy.Action {
+	case ActionDisable:
+		if !job.disabled.Swap(true) {
+			job.logger.Warn("disabling system after consecutive failures", "failures", policy.Threshold)
+		}
+	case ActionStop:
+		w.stop(fmt.Errorf("world: system %v has failed, %w", job.name, err))
+	}
+}
+
+// stop stops the simulation with a terminal error
+func (w *World[T]) stop(err error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.err == nil {
+		w.err = err
+		w.logger.Error("stopping simulation", "error", err)
+	}
+
+	if w.cancel != nil {
+		w.cancel()
+	}
+}
+
+// Err returns the terminal error which has stopped the simulation, if any.
+func (w *World[T]) Err() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	return w.err
+}
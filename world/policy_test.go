@@ -0,0 +1,84 @@
+package world
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyContinue(t *testing.T) {
+	var order []string
+	w := Create[any](9, 9, &fakeSystem{name: "a", order: &order, fail: errors.New("boom")})
+
+	var failures []string
+	w.OnError(func(system string, err error) {
+		failures = append(failures, system+": "+err.Error())
+	})
+
+	for i := 0; i < 3; i++ {
+		assert.Error(t, w.Step(time.Second))
+	}
+
+	assert.Equal(t, []string{"a", "a", "a"}, order)
+	assert.Equal(t, []string{"a: boom", "a: boom", "a: boom"}, failures)
+}
+
+func TestPolicyDisable(t *testing.T) {
+	var order []string
+	w := Create[any](9, 9,
+		&supervisedSystem{fakeSystem{name: "a", order: &order, fail: errors.New("boom")},
+			Policy{Action: ActionDisable, Threshold: 2}},
+		&fakeSystem{name: "b", order: &order},
+	)
+
+	assert.Error(t, w.Step(time.Second))
+	assert.Error(t, w.Step(time.Second))
+	assert.NoError(t, w.Step(time.Second))
+	assert.Equal(t, []string{"a", "b", "a", "b", "b"}, order)
+	assert.NoError(t, w.Err())
+}
+
+func TestPolicyReset(t *testing.T) {
+	var order []string
+	system := &fakeSystem{name: "a", order: &order, fail: errors.New("boom")}
+	w := Create[any](9, 9, system)
+	w.SetPolicy("a", Policy{Action: ActionDisable, Threshold: 2})
+
+	// Successful update resets the consecutive failures
+	assert.Error(t, w.Step(time.Second))
+	system.fail = nil
+	assert.NoError(t, w.Step(time.Second))
+	system.fail = errors.New("boom")
+	assert.Error(t, w.Step(time.Second))
+	assert.Error(t, w.Step(time.Second))
+	assert.NoError(t, w.Step(time.Second))
+	assert.Equal(t, []string{"a", "a", "a", "a"}, order)
+}
+
+func TestPolicyStop(t *testing.T) {
+	var order []string
+	w := Create[any](9, 9, &supervisedSystem{
+		fakeSystem{name: "panic", every: time.Millisecond, order: &order},
+		Policy{Action: ActionStop},
+	})
+
+	w.SetTimestep(time.Millisecond)
+	err := w.Simulate(context.Background())
+	assert.ErrorContains(t, err, "system panic has failed")
+	assert.Equal(t, err, w.Err())
+	assert.NoError(t, w.Close())
+}
+
+// ---------------------------------- Test system ----------------------------------
+
+type supervisedSystem struct {
+	fakeSystem
+	policy Policy
+}
+
+func (s *supervisedSystem) Policy() Policy {
+	return s.policy
+}
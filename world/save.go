@@ -0,0 +1,284 @@
+package world
+
+import (
+	"compress/flate"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kelindar/ecs/internal/atomicfile"
+	"github.com/kelindar/tile"
+	"go.uber.org/multierr"
+)
+
+// manifest represents the record of a save, which commits all of the files of the
+// world together. The files are first staged next to the current ones, then the
+// manifest is written, and only then the files are replaced. A save is therefore
+// either fully applied or not at all, even if the process crashes midway.
+type manifest struct {
+	Generation uint64    `json:"generation"`         // The sequence number of the save
+	Time       time.Time `json:"time"`               // The time of the save
+	Files      []string  `json:"files"`              // The files, relative to the save directory
+	Staged     bool      `json:"staged,omitempty"`   // Whether the files may still be staged
+	Rollback   bool      `json:"rollback,omitempty"` // Whether the logs may still need to be discarded
+}
+
+// saveFile represents a file of the save, along with the function writing it
+type saveFile struct {
+	name  string
+	write func(io.Writer) error
+}
+
+// Save saves the state of the world. The map and the collections are committed
+// together, so that opening the world never sees a mix of two different saves.
+func (w *World[T]) Save() error {
+	w.saving.Lock()
+	defer w.saving.Unlock()
+	defer func(start time.Time) {
+		w.logger.Info("save completed", "generation", w.generation, "duration", time.Since(start))
+	}(time.Now())
+
+	files := w.saveFiles()
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.name)
+	}
+
+	// Log the commits into new segments, as the snapshots will contain the previous ones
+	segments, err := w.rotateLogs()
+	if err != nil {
+		return err
+	}
+
+	// Stage all of the files, keeping the previous save intact
+	for _, file := range files {
+		if err := atomicfile.Stage(w.pathOf(file.name), file.write); err != nil {
+			return multierr.Append(err, w.discard(names))
+		}
+	}
+
+	// Commit the save by writing its manifest, then replace the files
+	m := manifest{
+		Generation: w.generation + 1,
+		Time:       time.Now().UTC(),
+		Files:      names,
+		Staged:     true,
+	}
+	if err := w.writeManifest(m); err != nil {
+		return multierr.Append(err, w.discard(names))
+	}
+
+	w.generation = m.Generation
+	if err := w.replace(names); err != nil {
+		return err
+	}
+
+	m.Staged = false
+	if err := w.writeManifest(m); err != nil {
+		return err
+	}
+
+	if err := w.truncateLogs(segments); err != nil {
+		return err
+	}
+
+	// Keep the save in the history, according to the retention rules
+	return w.archive(m)
+}
+
+// recoverSave completes the save that was interrupted after being committed, or
+// discards the one that was interrupted before.
+func (w *World[T]) recoverSave() error {
+	m, err := w.readManifest()
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return w.discard(w.layoutFiles())
+	case err != nil:
+		return err
+	}
+
+	w.generation = m.Generation
+	if !m.Staged {
+		return w.discard(w.layoutFiles())
+	}
+
+	w.logger.Warn("completing an interrupted save", "generation", m.Generation)
+	if err := w.replace(m.Files); err != nil {
+		return err
+	}
+
+	// A rolled back save must not replay the logs which follow the previous one
+	if m.Rollback {
+		if err := w.removeLogs(); err != nil {
+			return err
+		}
+	}
+
+	m.Staged, m.Rollback = false, false
+	return w.writeManifest(m)
+}
+
+// openLogs replays the write-ahead logs of the collections on top of the restored
+// snapshots, then logs every subsequent commit until the next save.
+func (w *World[T]) openLogs() error {
+	layout := w.options.Layout
+	return multierr.Combine(
+		w.Mobiles.OpenLog(w.pathOf(logOf(layout.Mobiles))),
+		w.Statics.OpenLog(w.pathOf(logOf(layout.Statics))),
+		w.Items.OpenLog(w.pathOf(logOf(layout.Items))),
+	)
+}
+
+// logError returns a callback which reports the failures to append to a log
+func (w *World[T]) logError(name string) func(error) {
+	return func(err error) {
+		w.logger.Error("unable to append to the log", "log", logOf(name), "error", err)
+	}
+}
+
+// rotateLogs starts new segments of the write-ahead logs of the collections
+func (w *World[T]) rotateLogs() (segments [3]uint64, err error) {
+	for i, rotate := range []func() (uint64, error){w.Mobiles.RotateLog, w.Statics.RotateLog, w.Items.RotateLog} {
+		if segments[i], err = rotate(); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// truncateLogs removes the segments of the write-ahead logs preceding the save
+func (w *World[T]) truncateLogs(segments [3]uint64) error {
+	return multierr.Combine(
+		w.Mobiles.TruncateLog(segments[0]),
+		w.Statics.TruncateLog(segments[1]),
+		w.Items.TruncateLog(segments[2]),
+	)
+}
+
+// closeLogs closes the write-ahead logs of the collections
+func (w *World[T]) closeLogs() error {
+	return multierr.Combine(
+		w.Mobiles.CloseLog(),
+		w.Statics.CloseLog(),
+		w.Items.CloseLog(),
+	)
+}
+
+// logOf returns the name of the write-ahead log of a collection file
+func logOf(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + ".log"
+}
+
+// replace replaces the files with their staged counterparts, if still staged
+func (w *World[T]) replace(names []string) error {
+	for _, name := range names {
+		if filename := w.pathOf(name); atomicfile.IsStaged(filename) {
+			if err := atomicfile.Commit(filename); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// discard removes the staged files
+func (w *World[T]) discard(names []string) (err error) {
+	for _, name := range names {
+		err = multierr.Append(err, atomicfile.Discard(w.pathOf(name)))
+	}
+	return
+}
+
+// saveFiles returns the files of the save, along with the functions writing them
+func (w *World[T]) saveFiles() []saveFile {
+	layout := w.options.Layout
+	return []saveFile{
+		{name: layout.Grid, write: w.writeGrid},
+		{name: layout.Mobiles, write: w.Mobiles.SnapshotTo},
+		{name: layout.Statics, write: w.Statics.SnapshotTo},
+		{name: layout.Items, write: w.Items.SnapshotTo},
+	}
+}
+
+// layoutFiles returns the names of the files of the save
+func (w *World[T]) layoutFiles() []string {
+	layout := w.options.Layout
+	return []string{layout.Grid, layout.Mobiles, layout.Statics, layout.Items}
+}
+
+// pathOf returns the path of a file within the save directory
+func (w *World[T]) pathOf(name string) string {
+	return filepath.Join(w.path, name)
+}
+
+// readManifest reads the manifest of the last save
+func (w *World[T]) readManifest() (manifest, error) {
+	return readManifestAt(w.pathOf(w.options.Layout.Manifest))
+}
+
+// writeManifest atomically writes the manifest of a save
+func (w *World[T]) writeManifest(m manifest) error {
+	return writeManifestAt(w.pathOf(w.options.Layout.Manifest), m)
+}
+
+// readManifestAt reads the manifest from the specified file
+func readManifestAt(filename string) (manifest, error) {
+	var m manifest
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return m, err
+	}
+
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+// writeManifestAt atomically writes the manifest into the specified file
+func writeManifestAt(filename string, m manifest) error {
+	return atomicfile.WriteFile(filename, func(dst io.Writer) error {
+		encoder := json.NewEncoder(dst)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(m)
+	})
+}
+
+// ---------------------------------- Grid ----------------------------------
+
+// restoreGrid restores the map from the save, along with its dimensions. If
+// the map was never saved, it saves the current one.
+func (w *World[T]) restoreGrid() error {
+	grid, err := tile.ReadFile[T](w.pathOf(w.options.Layout.Grid))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return w.saveGrid()
+	case err != nil:
+		return err
+	}
+
+	w.Grid = grid
+	w.options.Width = grid.Size.X
+	w.options.Height = grid.Size.Y
+	return nil
+}
+
+// saveGrid atomically writes the map into the save directory
+func (w *World[T]) saveGrid() error {
+	return atomicfile.WriteFile(w.pathOf(w.options.Layout.Grid), w.writeGrid)
+}
+
+// writeGrid writes the flate-compressed map, as read by tile.ReadFile
+func (w *World[T]) writeGrid(dst io.Writer) error {
+	writer, err := flate.NewWriter(dst, flate.BestSpeed)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Grid.WriteTo(writer); err != nil {
+		return err
+	}
+	return writer.Close()
+}
@@ -0,0 +1,184 @@
+package world
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kelindar/column"
+	"github.com/kelindar/ecs/entity"
+	"github.com/kelindar/ecs/internal/atomicfile"
+	"github.com/kelindar/tile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSave(t *testing.T) {
+	defer os.RemoveAll("temp")
+	w, err := Open[any]("temp")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), w.generation)
+
+	insertMobile(t, w, tile.At(1, 1))
+	assert.NoError(t, w.Save())
+	assert.NoError(t, w.Close())
+
+	m, err := w.readManifest()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), m.Generation)
+	assert.False(t, m.Staged)
+	assert.Equal(t, []string{"grid.bin", "mobiles.bin", "statics.bin", "items.bin"}, m.Files)
+
+	for _, name := range m.Files {
+		assert.FileExists(t, filepath.Join("temp", name))
+		assert.NoFileExists(t, filepath.Join("temp", name+atomicfile.Suffix))
+	}
+}
+
+func TestSaveLogs(t *testing.T) {
+	defer os.RemoveAll("temp")
+	w := openWithMobiles(t, 1)
+
+	// Changes since the last save are only in the log
+	insertMobile(t, w, tile.At(2, 2))
+	assert.NoError(t, w.Close())
+	assert.FileExists(t, filepath.Join("temp", "mobiles.log.3"))
+	assert.NoFileExists(t, filepath.Join("temp", "mobiles.log.2"))
+
+	w, err := Open[any]("temp")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, w.Mobiles.Count())
+	assert.Equal(t, []Entity{{KindMobile, 1}}, w.EntitiesAt(tile.At(2, 2)))
+
+	// Saving truncates the log which precedes the save
+	assert.NoError(t, w.Save())
+	assert.NoError(t, w.Close())
+	matches, _ := filepath.Glob(filepath.Join("temp", "mobiles.log.*"))
+	assert.Equal(t, []string{filepath.Join("temp", "mobiles.log.5")}, matches)
+}
+
+func TestSaveInterruptedBeforeCommit(t *testing.T) {
+	defer os.RemoveAll("temp")
+	w := openWithMobiles(t, 1)
+
+	// Crash while the files of the next save are staged
+	insertMobile(t, w, tile.At(2, 2))
+	stageAll(t, w)
+	assert.NoError(t, w.Close())
+
+	// The previous save is kept and the staged files are discarded, while the
+	// change is replayed from the log
+	w, err := Open[any]("temp")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, w.Mobiles.Count())
+	assert.Equal(t, uint64(2), w.generation)
+	assert.False(t, atomicfile.IsStaged(filepath.Join("temp", "mobiles.bin")))
+	assert.NoError(t, w.Close())
+}
+
+func TestSaveInterruptedAfterCommit(t *testing.T) {
+	defer os.RemoveAll("temp")
+	w := openWithMobiles(t, 1)
+
+	// Crash once the manifest is committed, but before the files are replaced
+	insertMobile(t, w, tile.At(2, 2))
+	stageAll(t, w)
+	assert.NoError(t, w.writeManifest(manifest{
+		Generation: 3,
+		Files:      w.layoutFiles(),
+		Staged:     true,
+	}))
+	assert.NoError(t, w.Close())
+
+	// The save is completed when opened
+	w, err := Open[any]("temp")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, w.Mobiles.Count())
+	assert.Equal(t, uint64(3), w.generation)
+	assert.Equal(t, []Entity{{KindMobile, 1}}, w.EntitiesAt(tile.At(2, 2)))
+
+	m, err := w.readManifest()
+	assert.NoError(t, err)
+	assert.False(t, m.Staged)
+	assert.NoError(t, w.Close())
+}
+
+func TestRollbackInterruptedAfterCommit(t *testing.T) {
+	defer os.RemoveAll("temp")
+	w := openWithMobiles(t, 1)
+
+	// Crash once the rollback to the current save is committed, before the logs are removed
+	insertMobile(t, w, tile.At(2, 2))
+	for _, file := range w.layoutFiles() {
+		assert.NoError(t, atomicfile.Stage(w.pathOf(file), copyOf(w.pathOf(file))))
+	}
+	assert.NoError(t, w.writeManifest(manifest{
+		Generation: 3,
+		Files:      w.layoutFiles(),
+		Staged:     true,
+		Rollback:   true,
+	}))
+	assert.NoError(t, w.Close())
+
+	// The rollback is completed when opened, without replaying the logs
+	w, err := Open[any]("temp")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, w.Mobiles.Count())
+	assert.Empty(t, w.EntitiesAt(tile.At(2, 2)))
+	assert.NoError(t, w.Close())
+}
+
+// openWithMobiles opens a new world with a number of mobiles saved into it
+func openWithMobiles(t *testing.T, count int) *World[any] {
+	w, err := Open[any]("temp")
+	assert.NoError(t, err)
+	for i := 0; i < count; i++ {
+		insertMobile(t, w, tile.At(1, 1))
+	}
+
+	assert.NoError(t, w.Save())
+	return w
+}
+
+// stageAll stages all of the files of the world, without committing them
+func stageAll(t *testing.T, w *World[any]) {
+	for _, file := range w.saveFiles() {
+		assert.NoError(t, atomicfile.Stage(w.pathOf(file.name), file.write))
+	}
+}
+
+func TestOpenMigrations(t *testing.T) {
+	defer os.RemoveAll("temp")
+
+	// Save the mobiles with an older schema, where the location was a tile index
+	legacy := entity.NewCollection("mobiles.bin", 0, func(*column.Txn) any { return nil })
+	legacy.CreateColumn("at", column.ForUint16())
+	legacy.Insert(func(any) error { return nil })
+	assert.NoError(t, legacy.Query(func(txn *column.Txn) error {
+		return txn.QueryAt(0, func(r column.Row) error {
+			r.SetUint16("at", 2*10+3)
+			return nil
+		})
+	}))
+	assert.NoError(t, legacy.SnapshotFile(filepath.Join("temp", "mobiles.bin")))
+
+	// Without the migration, the world cannot be opened
+	_, err := Open[any]("temp")
+	assert.ErrorContains(t, err, "no migration from version 0")
+
+	w, err := OpenWith[any]("temp", Options{
+		Migrations: Migrations{
+			Mobiles: []entity.Migration{{
+				From:   0,
+				Schema: column.Object{"at": uint16(0)},
+				Upgrade: func(row column.Object) error {
+					at := row["at"].(uint16)
+					row["at"] = tile.At(int16(at/10), int16(at%10)).Integer()
+					return nil
+				},
+			}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []Entity{{KindMobile, 0}}, w.EntitiesAt(tile.At(2, 3)))
+	assert.NoError(t, w.Close())
+}
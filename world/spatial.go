@@ -0,0 +1,274 @@
+package world
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/kelindar/column"
+	"github.com/kelindar/column/commit"
+	"github.com/kelindar/tile"
+)
+
+// Kind represents the kind of an entity, given by the collection it belongs to
+type Kind uint8
+
+// Various kinds of entities, one per collection of the world
+const (
+	KindMobile Kind = iota // An entity of the Mobiles collection
+	KindStatic             // An entity of the Statics collection
+	KindItem               // An entity of the Items collection
+)
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	switch k {
+	case KindMobile:
+		return "mobile"
+	case KindStatic:
+		return "static"
+	case KindItem:
+		return "item"
+	default:
+		return fmt.Sprintf("kind(%d)", k)
+	}
+}
+
+// Entity represents a reference to an entity of one of the collections
+type Entity struct {
+	Kind  Kind   // The collection of the entity
+	Index uint32 // The index of the entity within its collection
+}
+
+// EntitiesAt returns the entities located at the specified tile
+func (w *World[T]) EntitiesAt(at tile.Point) []Entity {
+	return w.space.at(at)
+}
+
+// EntitiesWithin returns the entities located within the specified rectangle,
+// excluding its maximum edges.
+func (w *World[T]) EntitiesWithin(rect tile.Rect) []Entity {
+	return w.space.within(rect, func(tile.Point) bool { return true })
+}
+
+// EntitiesAround returns the entities located within the manhattan distance from
+// the specified tile, inclusive.
+func (w *World[T]) EntitiesAround(at tile.Point, radius uint32) []Entity {
+	r := int32(min(radius, 1<<16))
+	rect := tile.Rect{
+		Min: tile.At(clamp(int32(at.X)-r), clamp(int32(at.Y)-r)),
+		Max: tile.At(clamp(int32(at.X)+r+1), clamp(int32(at.Y)+r+1)),
+	}
+
+	return w.space.within(rect, func(p tile.Point) bool {
+		return p.DistanceTo(at) <= radius
+	})
+}
+
+// clamp clamps the coordinate to the range of a tile point
+func clamp(v int32) int16 {
+	return int16(max(min(v, 1<<15-1), -1<<15))
+}
+
+// ---------------------------------- Index ----------------------------------
+
+// source represents a collection of entities with a location
+type source interface {
+	Query(fn func(txn *column.Txn) error) error
+	OnCommit(fn func(commit.Commit))
+}
+
+// index represents a spatial index of the entities, keyed by their location. It
+// is kept in sync with the "at" column of the collections by following their
+// commits, so every insert, move and delete is reflected, including the changes
+// done while iterating over a collection. Entities with no location are not indexed.
+type index struct {
+	lock    sync.RWMutex
+	reader  *commit.Reader           // The reader for the commits
+	tiles   map[tile.Point][]Entity  // The entities per tile
+	where   map[Entity]tile.Point    // The location per entity
+	rows    map[uint32]commit.OpType // The last row operations of a commit
+	subs    []*Subscription          // The subscriptions to the regions of the map
+	pending []notification           // The notifications to dispatch once unlocked
+}
+
+// newIndex creates a new empty spatial index
+func newIndex() *index {
+	return &index{
+		reader: commit.NewReader(),
+		tiles:  make(map[tile.Point][]Entity),
+		where:  make(map[Entity]tile.Point),
+		rows:   make(map[uint32]commit.OpType),
+	}
+}
+
+// watch keeps the index in sync with the changes of the collection
+func (x *index) watch(kind Kind, src source) {
+	src.OnCommit(func(c commit.Commit) {
+		x.apply(kind, c)
+	})
+}
+
+// rebuild replaces the indexed entities of a kind with the ones of the collection,
+// which is necessary after the collection was restored.
+func (x *index) rebuild(kind Kind, src source) error {
+	x.lock.Lock()
+	for entity := range x.where {
+		if entity.Kind == kind {
+			x.remove(entity)
+		}
+	}
+
+	err := src.Query(func(txn *column.Txn) error {
+		at := txn.Uint32("at")
+		return txn.Range(func(idx uint32) {
+			if v, ok := at.Get(); ok {
+				x.move(Entity{Kind: kind, Index: idx}, unpackPoint(v))
+			}
+		})
+	})
+
+	pending := x.flush()
+	x.lock.Unlock()
+	dispatch(pending)
+	return err
+}
+
+// apply applies the changes of a commit to the index and notifies the subscribers
+func (x *index) apply(kind Kind, c commit.Commit) {
+	x.lock.Lock()
+	x.update(kind, c)
+	pending := x.flush()
+	x.lock.Unlock()
+	dispatch(pending)
+}
+
+// update updates the index with the changes of a commit
+func (x *index) update(kind Kind, c commit.Commit) {
+	clear(x.rows)
+
+	// Remove the deleted rows first, since their index may be reused in the commit
+	for _, buffer := range c.Updates {
+		if buffer.Column == "row" {
+			x.reader.Range(buffer, c.Chunk, func(r *commit.Reader) {
+				for r.Next() {
+					x.rows[r.Index()] = r.Type
+					if r.Type == commit.Delete {
+						x.remove(Entity{Kind: kind, Index: r.Index()})
+					}
+				}
+			})
+		}
+	}
+
+	// Move the entities whose location has changed and were not deleted
+	for _, buffer := range c.Updates {
+		if buffer.Column == "at" {
+			x.reader.Range(buffer, c.Chunk, func(r *commit.Reader) {
+				for r.Next() {
+					if op, ok := x.rows[r.Index()]; r.Type == commit.Put && (!ok || op != commit.Delete) {
+						x.move(Entity{Kind: kind, Index: r.Index()}, unpackPoint(r.Uint32()))
+					}
+				}
+			})
+		}
+	}
+}
+
+// move moves the entity to the specified tile, adding it if necessary
+func (x *index) move(entity Entity, to tile.Point) {
+	from, ok := x.where[entity]
+	if ok {
+		if from == to {
+			return
+		}
+		x.unlink(entity, from)
+	}
+
+	x.where[entity] = to
+	x.tiles[to] = append(x.tiles[to], entity)
+	x.notify(entity, from, ok, to, true)
+}
+
+// remove removes the entity from the index, if present
+func (x *index) remove(entity Entity) {
+	from, ok := x.where[entity]
+	if !ok {
+		return
+	}
+
+	x.unlink(entity, from)
+	x.notify(entity, from, true, tile.Point{}, false)
+}
+
+// unlink removes the entity from the tile it is located at
+func (x *index) unlink(entity Entity, at tile.Point) {
+	delete(x.where, entity)
+	entities := x.tiles[at]
+	if i := slices.Index(entities, entity); i >= 0 {
+		entities[i] = entities[len(entities)-1]
+		entities = entities[:len(entities)-1]
+	}
+
+	if len(entities) == 0 {
+		delete(x.tiles, at)
+		return
+	}
+	x.tiles[at] = entities
+}
+
+// at returns a copy of the entities at the specified tile
+func (x *index) at(at tile.Point) []Entity {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+	return sortEntities(slices.Clone(x.tiles[at]))
+}
+
+// within returns the entities within the rectangle that satisfy the predicate
+func (x *index) within(rect tile.Rect, fn func(tile.Point) bool) (out []Entity) {
+	x.lock.RLock()
+	defer x.lock.RUnlock()
+	x.visit(rect, func(p tile.Point, entities []Entity) {
+		if fn(p) {
+			out = append(out, entities...)
+		}
+	})
+	return sortEntities(out)
+}
+
+// visit visits the occupied tiles within the rectangle, in no particular order
+func (x *index) visit(rect tile.Rect, fn func(tile.Point, []Entity)) {
+
+	// Visit the tiles of the rectangle only if there are fewer of them than occupied ones
+	width, height := int(rect.Max.X)-int(rect.Min.X), int(rect.Max.Y)-int(rect.Min.Y)
+	if width*height < len(x.tiles) {
+		for py := rect.Min.Y; py < rect.Max.Y; py++ {
+			for px := rect.Min.X; px < rect.Max.X; px++ {
+				if p := tile.At(px, py); len(x.tiles[p]) > 0 {
+					fn(p, x.tiles[p])
+				}
+			}
+		}
+		return
+	}
+
+	for p, entities := range x.tiles {
+		if rect.Contains(p) {
+			fn(p, entities)
+		}
+	}
+}
+
+// sortEntities sorts the entities by their kind and index, for a stable output
+func sortEntities(entities []Entity) []Entity {
+	slices.SortFunc(entities, func(a, b Entity) int {
+		return cmp.Or(cmp.Compare(a.Kind, b.Kind), cmp.Compare(a.Index, b.Index))
+	})
+	return entities
+}
+
+// unpackPoint decodes a tile point as stored in the "at" column
+func unpackPoint(v uint32) tile.Point {
+	return tile.At(int16(v>>16), int16(v))
+}
@@ -0,0 +1,88 @@
+package world
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kelindar/ecs/entity/item"
+	"github.com/kelindar/ecs/entity/mobile"
+	"github.com/kelindar/ecs/entity/static"
+	"github.com/kelindar/tile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpatialIndex(t *testing.T) {
+	w := Create[any](9, 9)
+	defer w.Close()
+
+	insertMobile(t, w, tile.At(1, 1))
+	insertMobile(t, w, tile.At(5, 5))
+	_, err := w.Statics.Insert(func(v static.Static) error {
+		v.SetLocation(tile.At(1, 1))
+		return nil
+	})
+	assert.NoError(t, err)
+	_, err = w.Items.Insert(func(v item.Item) error {
+		v.SetLocation(tile.At(2, 1))
+		return nil
+	})
+	assert.NoError(t, err)
+
+	// Insert
+	assert.Equal(t, []Entity{{KindMobile, 0}, {KindStatic, 0}}, w.EntitiesAt(tile.At(1, 1)))
+	assert.Empty(t, w.EntitiesAt(tile.At(0, 0)))
+	assert.Equal(t, []Entity{{KindMobile, 0}, {KindStatic, 0}, {KindItem, 0}},
+		w.EntitiesWithin(tile.NewRect(0, 0, 3, 3)))
+	assert.Equal(t, []Entity{{KindMobile, 0}, {KindStatic, 0}, {KindItem, 0}},
+		w.EntitiesAround(tile.At(2, 2), 2))
+	assert.Equal(t, []Entity{{KindItem, 0}}, w.EntitiesAround(tile.At(3, 1), 1))
+	assert.Equal(t, []Entity{{KindItem, 0}}, w.EntitiesWithin(tile.NewRect(2, 1, 3, 2)))
+
+	// Move while iterating
+	assert.NoError(t, w.Mobiles.Range(func(v mobile.Mobile) {
+		v.SetLocation(tile.At(v.Location().X+1, v.Location().Y))
+	}))
+	assert.Equal(t, []Entity{{KindStatic, 0}}, w.EntitiesAt(tile.At(1, 1)))
+	assert.Equal(t, []Entity{{KindMobile, 0}, {KindItem, 0}}, w.EntitiesAt(tile.At(2, 1)))
+	assert.Equal(t, []Entity{{KindMobile, 1}}, w.EntitiesAt(tile.At(6, 5)))
+
+	// Delete
+	assert.True(t, w.Mobiles.DeleteAt(0))
+	assert.Equal(t, []Entity{{KindItem, 0}}, w.EntitiesAt(tile.At(2, 1)))
+	assert.Equal(t, []Entity{{KindMobile, 1}, {KindStatic, 0}, {KindItem, 0}},
+		w.EntitiesWithin(tile.NewRect(0, 0, 9, 9)))
+}
+
+func TestSpatialRestore(t *testing.T) {
+	defer os.RemoveAll("temp")
+
+	{ // Create
+		w, err := Open[any]("temp")
+		assert.NoError(t, err)
+		insertMobile(t, w, tile.At(3, 4))
+		assert.NoError(t, w.Save())
+		assert.NoError(t, w.Close())
+	}
+
+	{ // Restore
+		w, err := Open[any]("temp")
+		assert.NoError(t, err)
+		assert.Equal(t, []Entity{{KindMobile, 0}}, w.EntitiesAt(tile.At(3, 4)))
+		assert.NoError(t, w.Close())
+	}
+}
+
+func TestKindString(t *testing.T) {
+	assert.Equal(t, "mobile", KindMobile.String())
+	assert.Equal(t, "static", KindStatic.String())
+	assert.Equal(t, "item", KindItem.String())
+	assert.Equal(t, "kind(9)", Kind(9).String())
+}
+
+func insertMobile(t *testing.T, w *World[any], at tile.Point) {
+	_, err := w.Mobiles.Insert(func(v mobile.Mobile) error {
+		v.SetLocation(at)
+		return nil
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,100 @@
+package world
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Buckets represents the upper bounds of the update duration histogram buckets,
+// the last bucket of the histogram counting the updates above the last bound.
+var Buckets = [...]time.Duration{
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// Stats represents the runtime statistics of a system
+type Stats struct {
+	Name      string                   // The name of the system
+	Updates   uint64                   // The number of updates
+	Errors    uint64                   // The number of updates that returned an error
+	Panics    uint64                   // The number of updates that panicked
+	Overruns  uint64                   // The number of updates that took longer than the interval
+	Total     time.Duration            // The total time spent updating
+	Max       time.Duration            // The longest update
+	Histogram [len(Buckets) + 1]uint64 // The number of updates per duration bucket
+}
+
+// Mean returns the average duration of an update
+func (s *Stats) Mean() time.Duration {
+	if s.Updates == 0 {
+		return 0
+	}
+	return s.Total / time.Duration(s.Updates)
+}
+
+// Quantile returns the upper bound of the histogram bucket which contains the
+// specified quantile (between 0 and 1) of the updates. For the updates above
+// the last bucket, the longest update is returned.
+func (s *Stats) Quantile(q float64) time.Duration {
+	if s.Updates == 0 {
+		return 0
+	}
+
+	rank := max(1, uint64(math.Ceil(q*float64(s.Updates))))
+	seen := uint64(0)
+	for i, count := range s.Histogram[:len(Buckets)] {
+		if seen += count; seen >= rank {
+			return Buckets[i]
+		}
+	}
+	return s.Max
+}
+
+// metrics collects the runtime statistics of a system
+type metrics struct {
+	lock  sync.Mutex
+	stats Stats
+}
+
+// record records an update of the system
+func (m *metrics) record(elapsed, budget time.Duration, err error, panicked bool) (overrun bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.stats.Updates++
+	m.stats.Total += elapsed
+	m.stats.Max = max(m.stats.Max, elapsed)
+	m.stats.Histogram[bucketOf(elapsed)]++
+	switch {
+	case panicked:
+		m.stats.Panics++
+	case err != nil:
+		m.stats.Errors++
+	}
+
+	if overrun = budget > 0 && elapsed > budget; overrun {
+		m.stats.Overruns++
+	}
+	return
+}
+
+// snapshot returns a copy of the statistics
+func (m *metrics) snapshot() Stats {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.stats
+}
+
+// bucketOf returns the histogram bucket for a duration
+func bucketOf(elapsed time.Duration) int {
+	for i, bound := range Buckets {
+		if elapsed <= bound {
+			return i
+		}
+	}
+	return len(Buckets)
+}
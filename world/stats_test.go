@@ -0,0 +1,56 @@
+package world
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	var order []string
+	w := Create[any](9, 9,
+		&fakeSystem{name: "ok", order: &order},
+		&fakeSystem{name: "panic", order: &order},
+	)
+
+	assert.Error(t, w.Step(time.Second))
+	assert.Error(t, w.Step(time.Second))
+
+	stats := w.Stats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, "ok", stats[0].Name)
+	assert.Equal(t, uint64(2), stats[0].Updates)
+	assert.Equal(t, uint64(0), stats[0].Panics)
+	assert.Equal(t, "panic", stats[1].Name)
+	assert.Equal(t, uint64(2), stats[1].Updates)
+	assert.Equal(t, uint64(2), stats[1].Panics)
+	assert.Equal(t, uint64(0), stats[1].Errors)
+}
+
+func TestMetricsRecord(t *testing.T) {
+	var m metrics
+	assert.False(t, m.record(50*time.Microsecond, time.Second, nil, false))
+	assert.False(t, m.record(5*time.Millisecond, time.Second, errors.New("boom"), false))
+	assert.True(t, m.record(2*time.Second, time.Second, nil, false))
+	assert.False(t, m.record(2*time.Second, 0, nil, true))
+
+	stats := m.snapshot()
+	assert.Equal(t, uint64(4), stats.Updates)
+	assert.Equal(t, uint64(1), stats.Errors)
+	assert.Equal(t, uint64(1), stats.Panics)
+	assert.Equal(t, uint64(1), stats.Overruns)
+	assert.Equal(t, 2*time.Second, stats.Max)
+	assert.Equal(t, [len(Buckets) + 1]uint64{1, 0, 1, 0, 0, 2}, stats.Histogram)
+	assert.Equal(t, 4005050*time.Microsecond/4, stats.Mean())
+	assert.Equal(t, 100*time.Microsecond, stats.Quantile(0.25))
+	assert.Equal(t, 10*time.Millisecond, stats.Quantile(0.5))
+	assert.Equal(t, 2*time.Second, stats.Quantile(0.99))
+}
+
+func TestStatsEmpty(t *testing.T) {
+	var stats Stats
+	assert.Zero(t, stats.Mean())
+	assert.Zero(t, stats.Quantile(0.5))
+}
@@ -1,8 +1,12 @@
 package world
 
 import (
+	"fmt"
+	"log/slog"
 	"reflect"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -13,8 +17,91 @@ type System[T comparable] interface {
 	Update(*Clock) error
 }
 
+// Phase represents a stage of a tick during which a system is updated
+type Phase uint8
+
+// Various phases of a tick, in the order of execution
+const (
+	PhasePreUpdate  Phase = iota // Input handling and preparation
+	PhaseUpdate                  // Game logic, default for systems
+	PhasePostUpdate              // Reactions to the game logic
+	PhasePersist                 // Persistence of the world state
+)
+
+// String returns the name of the phase
+func (p Phase) String() string {
+	switch p {
+	case PhasePreUpdate:
+		return "pre-update"
+	case PhaseUpdate:
+		return "update"
+	case PhasePostUpdate:
+		return "post-update"
+	case PhasePersist:
+		return "persist"
+	default:
+		return fmt.Sprintf("phase(%d)", p)
+	}
+}
+
+// Phased represents an optional contract for a system that runs during a specific
+// phase of a tick. Systems that do not implement it run during PhaseUpdate. In
+// real-time mode, the phases order the systems which are due at the same time.
+type Phased interface {
+	Phase() Phase
+}
+
+// Dependent represents an optional contract for a system that needs to run before
+// or after other systems within a tick. Systems are referred to by their name,
+// for example "movement" for the movement.System, and the dependencies on systems
+// that are not registered are ignored. In real-time mode, the dependencies order
+// the systems which are due at the same time, such as systems of equal intervals.
+type Dependent interface {
+	After() []string
+	Before() []string
+}
+
+// Access represents the set of resources a system reads and writes during its
+// update. A resource is either a collection of the world such as "mobiles", a
+// specific column of a collection such as "mobiles.at", the "grid", the definitions
+// of the "terrains" or the "spatial" index of the entities. The spatial index is
+// written by every system which changes the location of the entities.
+type Access struct {
+	Reads  []string // Resources read by the system
+	Writes []string // Resources written by the system
+}
+
+// Accessor represents an optional contract for a system that declares the resources
+// it accesses. Within a tick, systems whose writes do not overlap with resources
+// accessed by one another are updated concurrently. Systems that do not implement
+// it are always updated exclusively.
+type Accessor interface {
+	Access() Access
+}
+
+// conflicts returns whether two access sets cannot be used concurrently
+func (a *Access) conflicts(other *Access) bool {
+	return overlaps(a.Writes, other.Writes) ||
+		overlaps(a.Writes, other.Reads) ||
+		overlaps(a.Reads, other.Writes)
+}
+
+// overlaps returns whether any of the resources overlap, a collection being
+// overlapped by any of its columns.
+func overlaps(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y || strings.HasPrefix(x, y+".") || strings.HasPrefix(y, x+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // Clock represents a game clock
 type Clock struct {
+	Tick    uint64        // Current tick of the world, when advanced in ticks
 	Elapsed time.Duration // Elapsed time between frames
 	Current time.Time     // Current time, in unix seconds
 }
@@ -29,14 +116,114 @@ func newClock() *Clock {
 // Update updates the current clock
 func (c *Clock) Update() {
 	now := time.Now().UTC()
-	c.Elapsed = c.Current.Sub(now)
+	c.Elapsed = now.Sub(c.Current)
 	c.Current = now
 }
 
+// Advance advances the clock to a specific tick of a simulated timeline. The
+// current time is derived from the total simulated time so that the clock is
+// reproducible.
+func (c *Clock) Advance(tick uint64, elapsed, total time.Duration) {
+	c.Tick = tick
+	c.Elapsed = elapsed
+	c.Current = time.Unix(0, 0).UTC().Add(total)
+}
+
+// Logged represents an optional contract for a system that writes logs. The
+// logger, scoped to the system, is handed over right before the system is attached.
+type Logged interface {
+	SetLogger(*slog.Logger)
+}
+
+// Named represents an optional contract for a system that provides its own name,
+// instead of the one derived from its package.
+type Named interface {
+	Name() string
+}
+
 // nameOf prettifies system name
 func nameOf[T comparable](system System[T]) string {
+	if named, ok := system.(Named); ok {
+		return named.Name()
+	}
+
 	name := reflect.TypeOf(system).String()
 	name = strings.TrimSuffix(name, ".System")
 	name = strings.TrimPrefix(name, "*")
 	return name
 }
+
+// ---------------------------------- Job ----------------------------------
+
+// job represents a registered system along with its scheduling state
+type job[T comparable] struct {
+	System[T]
+	name     string                 // The prettified name of the system
+	clock    *Clock                 // The clock of the system
+	period   uint64                 // The number of ticks between updates, in fixed-timestep mode
+	phase    Phase                  // The phase during which the system runs
+	access   *Access                // The declared access, or nil if exclusive
+	stats    metrics                // The runtime statistics
+	policy   atomic.Pointer[Policy] // The error policy
+	failed   atomic.Uint32          // The number of consecutive failures
+	disabled atomic.Bool            // Whether the job was disabled by its policy
+	due      time.Time              // The time of the next update, in real-time mode
+	logger   *slog.Logger           // The logger scoped to the system
+}
+
+// newJob creates a new job for a system
+func newJob[T comparable](system System[T]) *job[T] {
+	job := &job[T]{
+		System: system,
+		name:   nameOf(system),
+		clock:  newClock(),
+		period: 1,
+		phase:  PhaseUpdate,
+		logger: slog.Default(),
+	}
+
+	if phased, ok := system.(Phased); ok {
+		job.phase = phased.Phase()
+	}
+
+	if accessor, ok := system.(Accessor); ok {
+		access := accessor.Access()
+		job.access = &access
+	}
+
+	policy := Policy{Action: ActionContinue}
+	if supervised, ok := system.(Supervised); ok {
+		policy = supervised.Policy()
+	}
+
+	job.policy.Store(&policy)
+	return job
+}
+
+// conflicts returns whether two jobs cannot be updated concurrently
+func (j *job[T]) conflicts(other *job[T]) bool {
+	return j.access == nil || other.access == nil ||
+		j.phase != other.phase ||
+		j.dependsOn(other) || other.dependsOn(j) ||
+		j.access.conflicts(other.access)
+}
+
+// dependsOn returns whether the job has declared an ordering with another job
+func (j *job[T]) dependsOn(other *job[T]) bool {
+	dependent, ok := j.System.(Dependent)
+	return ok && (slices.Contains(dependent.After(), other.name) ||
+		slices.Contains(dependent.Before(), other.name))
+}
+
+// schedule computes the number of ticks between updates for a fixed timestep
+func (j *job[T]) schedule(step time.Duration) {
+	j.period = 1
+	if interval := j.Interval(); step > 0 && interval > step {
+		j.period = uint64(interval / step)
+	}
+}
+
+// isDue returns whether the job needs to be updated at the specified tick
+func (j *job[T]) isDue(tick uint64) bool {
+	return tick%j.period == 0
+}
@@ -1 +1,102 @@
 package world
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFixedTimestep(t *testing.T) {
+	var order []string
+	fast := &fakeSystem{name: "fast", every: 100 * time.Millisecond, order: &order}
+	slow := &fakeSystem{name: "slow", every: 300 * time.Millisecond, order: &order}
+
+	w := Create[any](9, 9, fast, slow)
+	w.SetTimestep(100 * time.Millisecond)
+	for i := 0; i < 6; i++ {
+		w.advance()
+	}
+
+	assert.Equal(t, []string{
+		"fast", "fast", "fast", "slow", "fast", "fast", "fast", "slow",
+	}, order)
+
+	assert.Equal(t, uint64(6), fast.clock.Tick)
+	assert.Equal(t, 100*time.Millisecond, fast.clock.Elapsed)
+	assert.Equal(t, uint64(6), slow.clock.Tick)
+	assert.Equal(t, 300*time.Millisecond, slow.clock.Elapsed)
+	assert.Equal(t, time.Unix(0, 0).UTC().Add(600*time.Millisecond), slow.clock.Current)
+}
+
+func TestStep(t *testing.T) {
+	var order []string
+	fast := &fakeSystem{name: "fast", every: 100 * time.Millisecond, order: &order}
+	slow := &fakeSystem{name: "slow", every: time.Minute, order: &order}
+
+	w := Create[any](9, 9, fast, slow)
+	assert.NoError(t, w.Step(50*time.Millisecond))
+	assert.NoError(t, w.StepN(2, 25*time.Millisecond))
+	assert.Equal(t, []string{"fast", "slow", "fast", "slow", "fast", "slow"}, order)
+	assert.Equal(t, uint64(3), slow.clock.Tick)
+	assert.Equal(t, 25*time.Millisecond, slow.clock.Elapsed)
+	assert.Equal(t, time.Unix(0, 0).UTC().Add(100*time.Millisecond), slow.clock.Current)
+}
+
+func TestStepPanic(t *testing.T) {
+	var order []string
+	w := Create[any](9, 9, &fakeSystem{name: "panic", order: &order})
+	assert.Error(t, w.StepN(5, time.Second))
+	assert.Equal(t, []string{"panic"}, order)
+}
+
+func TestStepConcurrent(t *testing.T) {
+	var order1, order2 []string
+	w := Create[any](9, 9,
+		&accessSystem{fakeSystem{name: "a", order: &order1}, Access{Writes: []string{"mobiles"}}},
+		&accessSystem{fakeSystem{name: "b", order: &order2}, Access{Writes: []string{"items"}}},
+	)
+
+	assert.Len(t, w.batches, 1)
+	assert.NoError(t, w.StepN(3, time.Second))
+	assert.Equal(t, []string{"a", "a", "a"}, order1)
+	assert.Equal(t, []string{"b", "b", "b"}, order2)
+}
+
+func TestClockUpdate(t *testing.T) {
+	clock := newClock()
+	time.Sleep(time.Millisecond)
+	clock.Update()
+	assert.Greater(t, clock.Elapsed, time.Duration(0))
+}
+
+// ---------------------------------- Test system ----------------------------------
+
+type fakeSystem struct {
+	name  string
+	every time.Duration
+	order *[]string
+	clock Clock
+	fail  error
+}
+
+func (s *fakeSystem) Interval() time.Duration {
+	return s.every
+}
+
+func (s *fakeSystem) Attach(w *World[any]) error {
+	return nil
+}
+
+func (s *fakeSystem) Update(clock *Clock) error {
+	*s.order = append(*s.order, s.name)
+	s.clock = *clock
+	if s.name == "panic" {
+		panic("system failure")
+	}
+	return s.fail
+}
+
+func (s *fakeSystem) Name() string {
+	return s.name
+}
@@ -0,0 +1,145 @@
+package world
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/kelindar/tile"
+)
+
+// Traversal represents a set of ways a tile can be traversed
+type Traversal uint8
+
+// Various ways of traversing a tile
+const (
+	Walkable  Traversal = 1 << iota // The tile can be walked on
+	Swimmable                       // The tile can be swum through
+	Flyable                         // The tile can be flown over
+)
+
+// TerrainType represents the type of terrain of a tile. It is stored in the lowest
+// byte of the tile value, leaving the remaining bits available for other layers.
+type TerrainType uint8
+
+// Various built-in terrain types, which can be redefined
+const (
+	TerrainGround TerrainType = iota // Walkable ground, the default for every tile
+	TerrainWall                      // Impassable wall
+	TerrainWater                     // Water that can be swum through
+	TerrainMud                       // Walkable mud, twice as slow to move through
+)
+
+// terrainMask is the mask of the tile value which contains the terrain type
+const terrainMask tile.Value = 0xff
+
+// TerrainOf returns the terrain type encoded in the tile value
+func TerrainOf(v tile.Value) TerrainType {
+	return TerrainType(v & terrainMask)
+}
+
+// Terrain describes how a type of terrain can be traversed and seen through
+type Terrain struct {
+	Name   string    // The name of the terrain
+	Flags  Traversal // The ways the terrain can be traversed
+	Cost   uint16    // The cost multiplier to move through, where 1 is the normal cost
+	Opaque bool      // Whether the terrain blocks the line of sight
+}
+
+// Allows returns whether the terrain can be traversed in any of the specified ways
+func (t Terrain) Allows(mode Traversal) bool {
+	return t.Flags&mode != 0
+}
+
+// Terrains represents the table of terrain definitions, by their type
+type Terrains struct {
+	lock    sync.RWMutex
+	types   [256]Terrain
+	version atomic.Uint64
+}
+
+// newTerrains creates a new table with the built-in terrain definitions
+func newTerrains() *Terrains {
+	terrains := new(Terrains)
+	terrains.types[TerrainGround] = Terrain{Name: "ground", Flags: Walkable | Flyable, Cost: 1}
+	terrains.types[TerrainWall] = Terrain{Name: "wall", Opaque: true}
+	terrains.types[TerrainWater] = Terrain{Name: "water", Flags: Swimmable | Flyable, Cost: 1}
+	terrains.types[TerrainMud] = Terrain{Name: "mud", Flags: Walkable | Flyable, Cost: 2}
+	return terrains
+}
+
+// Define defines or redefines a type of terrain
+func (t *Terrains) Define(kind TerrainType, terrain Terrain) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.types[kind] = terrain
+	t.version.Add(1)
+}
+
+// Version returns the version of the terrain, which changes whenever a terrain
+// is defined or set on the map with SetTerrain.
+func (t *Terrains) Version() uint64 {
+	return t.version.Load()
+}
+
+// Get returns the definition of a type of terrain
+func (t *Terrains) Get(kind TerrainType) Terrain {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return t.types[kind]
+}
+
+// Find returns the type of the terrain defined with the specified name
+func (t *Terrains) Find(name string) (TerrainType, bool) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	for kind, terrain := range t.types {
+		if terrain.Name == name && name != "" {
+			return TerrainType(kind), true
+		}
+	}
+	return 0, false
+}
+
+// Of returns the definition of the terrain encoded in the tile value
+func (t *Terrains) Of(v tile.Value) Terrain {
+	return t.Get(TerrainOf(v))
+}
+
+// Cost returns a cost function for path finding on the grid, for the specified
+// ways of traversing. Tiles that cannot be traversed have a zero cost.
+func (t *Terrains) Cost(mode Traversal) func(tile.Value) uint16 {
+	return func(v tile.Value) uint16 {
+		terrain := t.Of(v)
+		switch {
+		case !terrain.Allows(mode):
+			return 0
+		case terrain.Cost == 0:
+			return 1
+		default:
+			return terrain.Cost
+		}
+	}
+}
+
+// SetTerrain sets the type of terrain of a tile and returns whether the tile is
+// within the bounds of the map.
+func (w *World[T]) SetTerrain(at tile.Point, kind TerrainType) bool {
+	if _, ok := w.Grid.At(at.X, at.Y); !ok {
+		return false
+	}
+
+	w.Grid.MaskAt(at.X, at.Y, tile.Value(kind), terrainMask)
+	w.Terrains.version.Add(1)
+	return true
+}
+
+// TerrainAt returns the terrain of a tile and whether the tile is within the
+// bounds of the map.
+func (w *World[T]) TerrainAt(at tile.Point) (Terrain, bool) {
+	t, ok := w.Grid.At(at.X, at.Y)
+	if !ok {
+		return Terrain{}, false
+	}
+
+	return w.Terrains.Of(t.Value()), true
+}
@@ -0,0 +1,54 @@
+package world
+
+import (
+	"testing"
+
+	"github.com/kelindar/tile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTerrain(t *testing.T) {
+	w := Create[any](9, 9)
+	defer w.Close()
+
+	// Every tile is ground by default
+	ground, ok := w.TerrainAt(tile.At(1, 1))
+	assert.True(t, ok)
+	assert.Equal(t, "ground", ground.Name)
+	assert.True(t, ground.Allows(Walkable))
+	assert.False(t, ground.Allows(Swimmable))
+
+	// Other layers of the tile value are preserved
+	w.Grid.WriteAt(1, 1, 0xab00)
+	version := w.Terrains.Version()
+	assert.True(t, w.SetTerrain(tile.At(1, 1), TerrainWall))
+	assert.Greater(t, w.Terrains.Version(), version)
+	assert.False(t, w.SetTerrain(tile.At(10, 1), TerrainWall))
+	cell, _ := w.Grid.At(1, 1)
+	assert.Equal(t, tile.Value(0xab01), cell.Value())
+
+	wall, ok := w.TerrainAt(tile.At(1, 1))
+	assert.True(t, ok)
+	assert.Equal(t, "wall", wall.Name)
+	assert.False(t, wall.Allows(Walkable|Swimmable|Flyable))
+	assert.True(t, wall.Opaque)
+
+	_, ok = w.TerrainAt(tile.At(-1, 1))
+	assert.False(t, ok)
+}
+
+func TestTerrainCost(t *testing.T) {
+	terrains := newTerrains()
+	terrains.Define(10, Terrain{Name: "bridge", Flags: Walkable})
+
+	walk := terrains.Cost(Walkable)
+	assert.Equal(t, uint16(1), walk(tile.Value(TerrainGround)))
+	assert.Equal(t, uint16(0), walk(tile.Value(TerrainWall)))
+	assert.Equal(t, uint16(0), walk(tile.Value(TerrainWater)))
+	assert.Equal(t, uint16(2), walk(tile.Value(TerrainMud)))
+	assert.Equal(t, uint16(1), walk(0xff00|10))
+
+	swim := terrains.Cost(Swimmable)
+	assert.Equal(t, uint16(1), swim(tile.Value(TerrainWater)))
+	assert.Equal(t, "bridge", terrains.Get(10).Name)
+}
@@ -2,10 +2,14 @@ package world
 
 import (
 	"context"
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"path/filepath"
 	"runtime/debug"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/kelindar/ecs/entity/item"
@@ -17,30 +21,106 @@ import (
 
 // World represents the entire game world state
 type World[T comparable] struct {
-	path    string             // The directory for save files
-	cancel  context.CancelFunc // Cancel function to stop everything
-	threads sync.WaitGroup     // Signals for each running system
-	systems []System[T]        // Attached systems
-	Grid    *tile.Grid[T]      // 3072x3072 map
-	Mobiles *mobile.Collection // List of mobiles (NPCs, Players, Monsters, ...)
-	Statics *static.Collection // List of objects on the map (Buildings, Trees, ...)
-	Items   *item.Collection   // List of items off map (Weapons, Potions, ...)
+	path       string                // The directory for save files
+	options    Options               // The options of the world
+	logger     *slog.Logger          // The logger to write to
+	cancel     context.CancelFunc    // Cancel function to stop everything
+	threads    sync.WaitGroup        // Signals for the running simulation
+	runners    sync.WaitGroup        // Signals for the goroutine updating the systems
+	sched      sync.Mutex            // Lock held during a tick and while changing systems
+	running    context.Context       // Context of the running simulation
+	wake       chan struct{}         // Signal that the schedule changed, in real-time mode
+	jobs       []*job[T]             // Attached systems
+	batches    [][]*job[T]           // Attached systems, grouped for concurrent updates
+	step       time.Duration         // Fixed timestep, zero when running in real-time
+	tick       uint64                // Current tick, in fixed-timestep mode
+	total      time.Duration         // Total simulated time, in fixed-timestep mode
+	paused     atomic.Bool           // Whether the simulation is paused
+	scale      atomic.Uint64         // Time scale, as float64 bits
+	lock       sync.Mutex            // Lock to guard the terminal error
+	err        error                 // Terminal error which stopped the simulation
+	onError    []func(string, error) // Callbacks for the system errors
+	saving     sync.Mutex            // Lock held while saving
+	generation uint64                // The generation of the last save
+	space      *index                // Spatial index of the entities
+	Grid       *tile.Grid[T]         // Map of the world, 3072x3072 by default
+	Terrains   *Terrains             // Definitions of the terrain types of the map
+	Mobiles    *mobile.Collection    // List of mobiles (NPCs, Players, Monsters, ...)
+	Statics    *static.Collection    // List of objects on the map (Buildings, Trees, ...)
+	Items      *item.Collection      // List of items off map (Weapons, Potions, ...)
 }
 
 // Open opens the world state file, or creates a new one
 func Open[T comparable](path string, systems ...System[T]) (*World[T], error) {
-	world := Create[T](3072, 3072) // Do not specify systems
+	return OpenWith(path, Options{}, systems...)
+}
+
+// OpenWith opens the world state file with the specified options, or creates a
+// new one. If the world was previously saved, the size of its map is restored
+// from the save, regardless of the size specified in the options.
+func OpenWith[T comparable](path string, options Options, systems ...System[T]) (*World[T], error) {
+	return open(path, options, time.Time{}, systems)
+}
+
+// OpenAt opens the world state file with the specified options, rolled back to the
+// most recent generation of the saves kept in its history at the specified time.
+// Every change since then, including the ones logged since the last save, is
+// discarded. The options must match the ones the world was saved with.
+func OpenAt[T comparable](path string, options Options, at time.Time, systems ...System[T]) (*World[T], error) {
+	return open(path, options, at, systems)
+}
+
+// open opens the world state file, rolling it back to a past generation of the
+// saves unless the time is zero.
+func open[T comparable](path string, options Options, at time.Time, systems []System[T]) (*World[T], error) {
+	world := newWorld[T](options.withDefaults())
 	world.path = path
 
-	// Load or create all of the collections
+	// Complete or discard the save that was interrupted, if any
+	if err := world.recoverSave(); err != nil {
+		return nil, err
+	}
+
+	// Replace the save with the past generation, if requested
+	if !at.IsZero() {
+		if err := world.rollback(at); err != nil {
+			return nil, err
+		}
+	}
+
+	// Load or create the map and all of the collections
+	layout, migrations := world.options.Layout, world.options.Migrations
+	if err := multierr.Combine(
+		world.restoreGrid(),
+		world.Mobiles.RestoreFile(filepath.Join(path, layout.Mobiles), migrations.Mobiles...),
+		world.Statics.RestoreFile(filepath.Join(path, layout.Statics), migrations.Statics...),
+		world.Items.RestoreFile(filepath.Join(path, layout.Items), migrations.Items...),
+	); err != nil {
+		return nil, err
+	}
+
+	// Replay the commits which were logged since the last save
+	if err := world.openLogs(); err != nil {
+		return nil, err
+	}
+
+	// Index the restored entities, since the snapshots are loaded without notifying
+	// the hooks of the collections, unlike the commits replayed from the logs
 	if err := multierr.Combine(
-		world.Mobiles.Restore(path),
-		world.Statics.Restore(path),
-		world.Items.Restore(path),
+		world.space.rebuild(KindMobile, world.Mobiles),
+		world.space.rebuild(KindStatic, world.Statics),
+		world.space.rebuild(KindItem, world.Items),
 	); err != nil {
 		return nil, err
 	}
 
+	// Commit the files of a new world together, so that the next open is consistent
+	if world.generation == 0 {
+		if err := world.Save(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Register all of the provided systems
 	if err := world.register(systems); err != nil {
 		return nil, err
@@ -51,12 +131,7 @@ func Open[T comparable](path string, systems ...System[T]) (*World[T], error) {
 
 // Create creates a new empty world
 func Create[T comparable](width, height int16, systems ...System[T]) *World[T] {
-	world := &World[T]{
-		Grid:    tile.NewGridOf[T](width, height),
-		Mobiles: mobile.NewCollection(),
-		Statics: static.NewCollection(),
-		Items:   item.NewCollection(),
-	}
+	world := newWorld[T](Options{Width: width, Height: height}.withDefaults())
 
 	// If systems are specified, attach them right away
 	if err := world.register(systems); err != nil {
@@ -65,73 +140,394 @@ func Create[T comparable](width, height int16, systems ...System[T]) *World[T] {
 	return world
 }
 
-// Save saves the state of the world
-func (w *World[T]) Save() error {
-	defer func(start time.Time) {
-		log.Printf("world: save completed (%v)", time.Now().Sub(start))
-	}(time.Now())
-	return multierr.Combine(
-		w.Mobiles.Snapshot(w.path),
-		w.Statics.Snapshot(w.path),
-		w.Items.Snapshot(w.path),
-	)
+// newWorld creates a new empty world with the specified options
+func newWorld[T comparable](options Options) *World[T] {
+	world := &World[T]{
+		options:  options,
+		logger:   newLogger(options),
+		step:     options.Timestep,
+		Grid:     tile.NewGridOf[T](options.Width, options.Height),
+		Mobiles:  mobile.NewCollection(),
+		Statics:  static.NewCollection(),
+		Items:    item.NewCollection(),
+		space:    newIndex(),
+		Terrains: newTerrains(),
+	}
+
+	// Keep the spatial index in sync with the collections
+	world.space.watch(KindMobile, world.Mobiles)
+	world.space.watch(KindStatic, world.Statics)
+	world.space.watch(KindItem, world.Items)
+
+	// Report the failures to log the commits as soon as they happen
+	world.Mobiles.OnLogError(world.logError(options.Layout.Mobiles))
+	world.Statics.OnLogError(world.logError(options.Layout.Statics))
+	world.Items.OnLogError(world.logError(options.Layout.Items))
+
+	// Time elapses at the real-time rate by default
+	world.SetTimeScale(1.0)
+	return world
+}
+
+// Options returns the options the world was created with
+func (w *World[T]) Options() Options {
+	return w.options
 }
 
-// Register registers all of the systems and atta
-func (w *World[T]) register(systems []System[T]) error {
-	w.systems = systems
+// Register registers all of the systems and attaches them to the world. If any
+// of the systems cannot be registered, the ones attached so far are closed and
+// none of them is registered.
+func (w *World[T]) register(systems []System[T]) (err error) {
+	for i, system := range systems {
+		if w.isAttached(system) || slices.Contains(systems[:i], system) {
+			return fmt.Errorf("world: system %v is already attached", nameOf(system))
+		}
+	}
+
+	// Close the systems attached so far, should the registration fail
+	jobs := slices.Clone(w.jobs)
+	defer func() {
+		if err != nil {
+			for _, job := range jobs[len(w.jobs):] {
+				if closer, ok := job.System.(io.Closer); ok {
+					err = multierr.Append(err, closer.Close())
+				}
+			}
+		}
+	}()
+
 	for _, system := range systems {
-		log.Printf("world: attaching %v system", nameOf(system))
+		logger := w.logger.With("system", nameOf(system))
+
+		// Hand the scoped logger to the system before attaching it
+		if logged, ok := system.(Logged); ok {
+			logged.SetLogger(logger)
+		}
+
+		logger.Info("attaching system")
 		if err := system.Attach(w); err != nil {
 			return err
 		}
+
+		// The interval, phase and access may depend on the options of the world,
+		// hence the job is only built once the system is attached.
+		job := newJob(system)
+		job.logger = logger
+		job.schedule(w.step)
+		job.due = time.Now().Add(job.Interval())
+		jobs = append(jobs, job)
+	}
+
+	// Order the systems according to their phases and dependencies
+	sorted, err := sortJobs(jobs)
+	if err != nil {
+		return err
+	}
+
+	w.jobs = sorted
+	w.batches = batchJobs(sorted)
+	return nil
+}
+
+// isAttached returns whether the system is already attached to the world
+func (w *World[T]) isAttached(system System[T]) bool {
+	return slices.ContainsFunc(w.jobs, func(job *job[T]) bool {
+		return job.System == system
+	})
+}
+
+// Attach attaches a system to the world, which can be done while the world is
+// being simulated, in which case the system is attached in between two updates.
+// This must not be called from within a system update.
+func (w *World[T]) Attach(system System[T]) error {
+	w.sched.Lock()
+	defer w.sched.Unlock()
+	if err := w.register([]System[T]{system}); err != nil {
+		return err
+	}
+
+	// In real-time mode, the new system may be due before the next update
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Detach detaches a system from the world and closes it if it implements the
+// io.Closer interface, which can be done while the world is being simulated. It
+// waits for any ongoing update of the system to complete and must not be called
+// from within a system update.
+func (w *World[T]) Detach(system System[T]) error {
+	w.sched.Lock()
+	defer w.sched.Unlock()
+
+	idx := slices.IndexFunc(w.jobs, func(job *job[T]) bool {
+		return job.System == system
+	})
+	if idx < 0 {
+		return fmt.Errorf("world: system %v is not attached", nameOf(system))
+	}
+
+	// Removing a system does not change the order of the remaining ones
+	job := w.jobs[idx]
+	w.jobs = slices.Delete(slices.Clone(w.jobs), idx, idx+1)
+	w.batches = batchJobs(w.jobs)
+
+	job.logger.Info("detaching system")
+	if closer, ok := system.(io.Closer); ok {
+		return closer.Close()
 	}
 	return nil
 }
 
-// Simulate runs the world simulation loop by starting all of the registered
-// systems asynchronously.
+// SetTimestep switches the world into a deterministic, fixed-timestep mode where
+// the world advances in discrete ticks of the specified duration. A system is
+// updated every N ticks, where N is its interval divided by the step. A zero step
+// restores the default real-time mode, where every system is updated on its own
+// interval of the wall clock. This must be called before Simulate.
+func (w *World[T]) SetTimestep(step time.Duration) {
+	w.step = step
+	for _, job := range w.jobs {
+		job.schedule(step)
+	}
+}
+
+// Simulate runs the world simulation loop by updating all of the registered
+// systems asynchronously. In both modes, the systems which are due at the same
+// time are updated in the order of their phases and dependencies, concurrently
+// only when their declared access does not conflict. It blocks until the world
+// is closed or stopped by the error policy of one of the systems, in which case
+// the terminal error is returned.
 func (w *World[T]) Simulate(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	w.cancel = cancel
 
-	// Every system will run in a separate goroutine
-	w.threads.Add(len(w.systems))
-	for _, system := range w.systems {
-		go w.runSystem(ctx, system)
+	// A single goroutine drives all of the systems
+	w.sched.Lock()
+	w.running = ctx
+	w.threads.Add(1)
+	w.runners.Add(1)
+	if w.step > 0 {
+		go w.runFixed(ctx)
+		w.sched.Unlock()
+		w.logger.Info("simulation started", "timestep", w.step)
+		w.threads.Wait()
+		return w.Err()
+	}
+
+	// The intervals of the systems start elapsing from now on
+	now := time.Now()
+	for _, job := range w.jobs {
+		job.due = now.Add(job.Interval())
 	}
 
-	log.Printf("world: started successfully")
+	w.wake = make(chan struct{}, 1)
+	go w.runRealtime(ctx)
+	w.sched.Unlock()
+	w.logger.Info("simulation started")
 	w.threads.Wait()
-	return nil
+	return w.Err()
+}
+
+// runRealtime runs all of the systems on their own intervals of the wall clock
+func (w *World[T]) runRealtime(ctx context.Context) {
+	w.sched.Lock()
+	timer := time.NewTimer(time.Until(w.nextDue()))
+	w.sched.Unlock()
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			w.runners.Done()
+			w.threads.Done()
+			return
+		case <-w.wake:
+		case <-timer.C:
+		}
+
+		w.sched.Lock()
+		w.elapse(time.Now())
+		timer.Reset(time.Until(w.nextDue()))
+		w.sched.Unlock()
+	}
 }
 
-// runSystem runs a system
-func (w *World[T]) runSystem(ctx context.Context, system System[T]) {
-	clock := newClock()
-	timer := time.NewTicker(system.Interval())
+// elapse updates every system that is due at the specified time, in the order
+// of their phases and dependencies. Like a ticker, a system which falls behind
+// skips the updates it has missed.
+func (w *World[T]) elapse(now time.Time) {
+	w.updateAll(func(job *job[T]) bool {
+		if now.Before(job.due) {
+			return false
+		}
+
+		if job.due = job.due.Add(job.Interval()); !job.due.After(now) {
+			job.due = now.Add(job.Interval())
+		}
+
+		job.clock.Update()
+		if w.IsPaused() {
+			return false
+		}
+
+		job.clock.Elapsed = w.scaled(job.clock.Elapsed)
+		return true
+	})
+}
 
-	// Update wraps the system update method and a panic handler
-	update := func() {
-		defer handlePanic()
-		if err := system.Update(clock); err != nil {
-			log.Printf("error: %+v", err)
+// nextDue returns the time at which the next system is due, in real-time mode
+func (w *World[T]) nextDue() time.Time {
+	next := time.Now().Add(time.Hour)
+	for _, job := range w.jobs {
+		if !job.disabled.Load() && job.due.Before(next) {
+			next = job.due
 		}
 	}
+	return next
+}
 
+// runFixed runs all of the systems sequentially, on a fixed timestep
+func (w *World[T]) runFixed(ctx context.Context) {
+	timer := time.NewTicker(w.step)
 	for {
 		select {
 		case <-ctx.Done():
 			timer.Stop()
+			w.runners.Done()
 			w.threads.Done()
 			return
 		case <-timer.C:
-			update()
+			w.sched.Lock()
+			w.advance()
+			w.sched.Unlock()
+		}
+	}
+}
+
+// advance advances the world by a single tick and updates every system that
+// is due at that tick, in the order of their phases and dependencies.
+func (w *World[T]) advance() {
+	if w.IsPaused() {
+		return
+	}
+
+	w.tick++
+	w.total += w.scaled(w.step)
+	w.updateAll(func(job *job[T]) bool {
+		if !job.isDue(w.tick) {
+			return false
+		}
+
+		job.clock.Advance(w.tick, w.scaled(time.Duration(job.period)*w.step), w.total)
+		return true
+	})
+}
+
+// Step advances the world by a single tick of the specified duration and updates
+// every registered system exactly once, synchronously and in order, regardless
+// of their intervals. This does not require Simulate to be running and is meant
+// for driving the world from tests and tools with a synthetic clock, hence it is
+// neither affected by the pause nor by the time scale.
+func (w *World[T]) Step(dt time.Duration) error {
+	w.sched.Lock()
+	defer w.sched.Unlock()
+
+	w.tick++
+	w.total += dt
+	return w.updateAll(func(job *job[T]) bool {
+		job.clock.Advance(w.tick, dt, w.total)
+		return true
+	})
+}
+
+// StepN advances the world by n ticks of the specified duration, stopping at
+// the first tick during which one of the systems has failed.
+func (w *World[T]) StepN(n int, dt time.Duration) error {
+	for i := 0; i < n; i++ {
+		if err := w.Step(dt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateAll updates all of the enabled jobs selected by the prepare function,
+// batch by batch. The jobs within a batch are updated concurrently and the
+// errors of all of the updates are combined together.
+func (w *World[T]) updateAll(prepare func(*job[T]) bool) error {
+	var errs error
+	for _, batch := range w.batches {
+		due := make([]*job[T], 0, len(batch))
+		for _, job := range batch {
+			if !job.disabled.Load() && prepare(job) {
+				due = append(due, job)
+			}
+		}
+
+		// Avoid spinning up goroutines when there's nothing to run concurrently
+		if len(due) == 1 {
+			errs = multierr.Append(errs, w.update(due[0]))
+			continue
+		}
+
+		var wg sync.WaitGroup
+		result := make([]error, len(due))
+		for i := range due {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				result[i] = w.update(due[i])
+			}(i)
 		}
+
+		wg.Wait()
+		errs = multierr.Append(errs, multierr.Combine(result...))
+	}
+	return errs
+}
+
+// update wraps the system update method with a panic handler, logs the errors
+// and records its runtime statistics.
+func (w *World[T]) update(job *job[T]) (err error) {
+	start := time.Now()
+	defer func() {
+		panicked := handlePanic(recover(), &err)
+		switch {
+		case panicked:
+			job.logger.Error("system panicked", "tick", job.clock.Tick, "error", err,
+				"stack", string(debug.Stack()))
+		case err != nil:
+			job.logger.Error("system update failed", "tick", job.clock.Tick, "error", err)
+		}
+
+		elapsed := time.Since(start)
+		if job.stats.record(elapsed, job.Interval(), err, panicked) {
+			job.logger.Warn("system update exceeded its budget", "tick", job.clock.Tick,
+				"duration", elapsed, "budget", job.Interval())
+		}
+
+		w.supervise(job, err)
+	}()
+	return job.Update(job.clock)
+}
+
+// Stats returns the runtime statistics of every registered system
+func (w *World[T]) Stats() []Stats {
+	w.sched.Lock()
+	defer w.sched.Unlock()
+
+	stats := make([]Stats, 0, len(w.jobs))
+	for _, job := range w.jobs {
+		s := job.stats.snapshot()
+		s.Name = job.name
+		stats = append(stats, s)
 	}
+	return stats
 }
 
-// Close saves the state of the world and closes it
+// Close stops the simulation, waits for the systems to stop updating, closes the
+// systems which implement the io.Closer interface and stops logging the commits.
 func (w *World[T]) Close() error {
 	w.threads.Add(1) // Wait for closing
 	if w.cancel != nil {
@@ -139,23 +535,39 @@ func (w *World[T]) Close() error {
 	}
 
 	// Wait for all systems to stop updating, then attempt to close them
-	for _, system := range w.systems {
-		if closer, ok := system.(io.Closer); ok {
+	w.runners.Wait()
+	w.sched.Lock()
+	for _, job := range w.jobs {
+		if closer, ok := job.System.(io.Closer); ok {
 			if err := closer.Close(); err != nil {
-				log.Printf("world: unable to close %T, %+v", system, err)
+				job.logger.Error("unable to close system", "error", err)
 			}
 		}
 	}
 
-	// Done closing
+	// Done closing, stop logging the commits once the systems are closed
+	w.sched.Unlock()
 	w.threads.Done()
 	w.threads.Wait()
-	return nil
+	return w.closeLogs()
 }
 
-// handlePanic handles the panic and logs it out
-func handlePanic() {
-	if r := recover(); r != nil {
-		log.Printf("panic: %s \n %s", r, debug.Stack())
+// newLogger creates the logger of the world, scoped to its shard
+func newLogger(options Options) *slog.Logger {
+	logger := slog.New(options.Handler)
+	if options.Shard != "" {
+		logger = logger.With("shard", options.Shard)
 	}
+	return logger
+}
+
+// handlePanic converts the recovered panic into an error. It returns whether a
+// panic has actually occurred.
+func handlePanic(r any, err *error) bool {
+	if r == nil {
+		return false
+	}
+
+	*err = fmt.Errorf("panic: %v", r)
+	return true
 }
@@ -1,8 +1,11 @@
 package world
 
 import (
+	"context"
 	"os"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -11,16 +14,201 @@ func TestWorldOpen(t *testing.T) {
 	defer os.RemoveAll("temp")
 
 	{ // Create
-		w, err := Open("temp")
+		w, err := Open[any]("temp")
 		assert.NoError(t, err)
 		assert.NotNil(t, w)
 		assert.NoError(t, w.Close())
 	}
 
 	{ // Restore
-		w, err := Open("temp")
+		w, err := Open[any]("temp")
 		assert.NoError(t, err)
 		assert.NotNil(t, w)
 		assert.NoError(t, w.Close())
 	}
 }
+
+func TestAttachDetach(t *testing.T) {
+	var order []string
+	a := &fakeSystem{name: "a", order: &order}
+	b := &closerSystem{fakeSystem: fakeSystem{name: "b", order: &order}}
+	w := Create[any](9, 9, a)
+
+	assert.NoError(t, w.Attach(b))
+	assert.NoError(t, w.Step(time.Second))
+	assert.NoError(t, w.Detach(b))
+	assert.True(t, b.closed)
+	assert.NoError(t, w.Step(time.Second))
+	assert.Equal(t, []string{"a", "b", "a"}, order)
+
+	assert.Error(t, w.Detach(b))
+	assert.Error(t, w.Attach(&orderedSystem{fakeSystem{name: "c"}, PhaseUpdate, []string{"c"}, nil}))
+	assert.Len(t, w.Stats(), 1)
+}
+
+func TestAttachDuplicate(t *testing.T) {
+	a := &fakeSystem{name: "a"}
+	w := Create[any](9, 9, a)
+	assert.Error(t, w.Attach(a))
+	assert.Len(t, w.Stats(), 1)
+
+	b := &fakeSystem{name: "b"}
+	assert.Error(t, w.register([]System[any]{b, b}))
+	assert.Len(t, w.Stats(), 1)
+}
+
+func TestAttachRollback(t *testing.T) {
+	w := Create[any](9, 9)
+	b := &closerSystem{fakeSystem: fakeSystem{name: "b"}}
+	c := &orderedSystem{fakeSystem{name: "c"}, PhaseUpdate, []string{"c"}, nil}
+
+	// The systems attached before an invalid ordering are closed
+	assert.Error(t, w.register([]System[any]{b, c}))
+	assert.True(t, b.closed)
+	assert.Len(t, w.Stats(), 0)
+}
+
+func TestAttachLive(t *testing.T) {
+	for _, step := range []time.Duration{0, time.Millisecond} {
+		w := Create[any](9, 9)
+		w.SetTimestep(step)
+		go w.Simulate(context.Background())
+
+		counter := new(counterSystem)
+		assert.Eventually(t, func() bool {
+			return w.Attach(counter) == nil
+		}, time.Second, time.Millisecond)
+
+		assert.Eventually(t, func() bool {
+			return counter.count.Load() > 3
+		}, time.Second, time.Millisecond)
+
+		// Once detached, the system is no longer updated
+		assert.NoError(t, w.Detach(counter))
+		count := counter.count.Load()
+		time.Sleep(10 * time.Millisecond)
+		assert.Equal(t, count, counter.count.Load())
+		assert.NoError(t, w.Close())
+	}
+}
+
+func TestCloseWaits(t *testing.T) {
+	for _, step := range []time.Duration{0, time.Millisecond} {
+		system := new(lateSystem)
+		w := Create[any](9, 9, system)
+		w.SetTimestep(step)
+		go w.Simulate(context.Background())
+		assert.Eventually(t, func() bool {
+			return system.count.Load() > 3
+		}, time.Second, time.Millisecond)
+
+		// No update may happen once the system is closed
+		assert.NoError(t, w.Close())
+		time.Sleep(10 * time.Millisecond)
+		assert.False(t, system.late.Load())
+	}
+}
+
+func TestRealtimeAccess(t *testing.T) {
+	busy, overlap := new(atomic.Int32), new(atomic.Bool)
+	a := &busySystem{busy: busy, overlap: overlap, access: Access{Writes: []string{"mobiles"}}}
+	b := &busySystem{busy: busy, overlap: overlap, access: Access{Reads: []string{"mobiles.at"}}}
+	w := Create[any](9, 9, a, b)
+	go w.Simulate(context.Background())
+
+	// Systems which access the same resources are never updated concurrently
+	assert.Eventually(t, func() bool {
+		return a.count.Load() > 10 && b.count.Load() > 10
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, w.Close())
+	assert.False(t, overlap.Load())
+}
+
+func TestRealtimeOrder(t *testing.T) {
+	var order []string
+	w := Create[any](9, 9,
+		&orderedSystem{fakeSystem{name: "a", every: time.Millisecond, order: &order}, PhaseUpdate, []string{"b"}, nil},
+		&orderedSystem{fakeSystem{name: "b", every: time.Millisecond, order: &order}, PhaseUpdate, nil, nil},
+		&orderedSystem{fakeSystem{name: "c", every: time.Millisecond, order: &order}, PhasePreUpdate, nil, nil},
+	)
+	go w.Simulate(context.Background())
+
+	// Systems which are due at the same time are updated in order
+	assert.Eventually(t, func() bool {
+		return w.Stats()[0].Updates > 3
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, w.Close())
+	assert.GreaterOrEqual(t, len(order), 6)
+	for i := 0; i+2 < len(order); i += 3 {
+		assert.Equal(t, []string{"c", "b", "a"}, order[i:i+3])
+	}
+}
+
+// ---------------------------------- Test systems ----------------------------------
+
+type closerSystem struct {
+	fakeSystem
+	closed bool
+}
+
+func (s *closerSystem) Close() error {
+	s.closed = true
+	return nil
+}
+
+type counterSystem struct {
+	count atomic.Int32
+}
+
+func (s *counterSystem) Interval() time.Duration {
+	return time.Millisecond
+}
+
+func (s *counterSystem) Attach(w *World[any]) error {
+	return nil
+}
+
+func (s *counterSystem) Update(clock *Clock) error {
+	s.count.Add(1)
+	return nil
+}
+
+type lateSystem struct {
+	counterSystem
+	closed atomic.Bool
+	late   atomic.Bool
+}
+
+func (s *lateSystem) Update(clock *Clock) error {
+	time.Sleep(time.Millisecond)
+	if s.closed.Load() {
+		s.late.Store(true)
+	}
+	return s.counterSystem.Update(clock)
+}
+
+func (s *lateSystem) Close() error {
+	s.closed.Store(true)
+	return nil
+}
+
+type busySystem struct {
+	counterSystem
+	busy    *atomic.Int32
+	overlap *atomic.Bool
+	access  Access
+}
+
+func (s *busySystem) Access() Access {
+	return s.access
+}
+
+func (s *busySystem) Update(clock *Clock) error {
+	if s.busy.Add(1) > 1 {
+		s.overlap.Store(true)
+	}
+
+	time.Sleep(100 * time.Microsecond)
+	s.busy.Add(-1)
+	return s.counterSystem.Update(clock)
+}